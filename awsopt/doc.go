@@ -0,0 +1,21 @@
+/*
+Package awsopt implements options for AWS CloudWatch Logs and Lambda logging.
+This option is created by referring to the following.
+	https://docs.aws.amazon.com/lambda/latest/dg/monitoring-cloudwatchlogs-advanced.html
+	https://docs.aws.amazon.com/AmazonCloudWatch/latest/logs/CWL_AnalyzeLogData-discoverable-fields.html
+	https://docs.aws.amazon.com/AmazonCloudWatch/latest/monitoring/CloudWatch_Embedded_Metric_Format_Specification.html
+
+Usage:
+	log.SetOutput(logplug.NewJSONPlug(os.Stdout, awsopt.NewAWSOptions("INFO")...))
+	log.SetFlags(awsopt.LogFlags)
+	log.Print("[WARN] slow request")
+	// output: {"level":"WARN","location":"main.go:12","message":"slow request","timestamp":"2006-01-02T15:04:05.999999Z","xray_trace_id":"1-5759e988-bd862e3fe1be46a994272793"}
+
+Metrics can be emitted through log lines with Embedded Metric Format.
+	logplug.NewJSONPlug(os.Stdout, append(awsopt.NewAWSOptions("INFO"),
+		logplug.Hooks(awsopt.EMFHook("MyApp", "service")),
+	)...)
+	log.Printf("[service:api]%s request done", awsopt.Metric("Latency", 12.5, awsopt.UnitMilliseconds))
+	// output: {"Latency":12.5,"_aws":{"Timestamp":1136214245999,"CloudWatchMetrics":[{"Namespace":"MyApp","Dimensions":[["service"]],"Metrics":[{"Name":"Latency","Unit":"Milliseconds"}]}]},"level":"INFO",...,"service":"api"}
+*/
+package awsopt
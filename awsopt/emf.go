@@ -0,0 +1,114 @@
+package awsopt
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/komem3/logplug"
+)
+
+// MetricPrefix is key prefix of metric fields.
+const MetricPrefix = "metric."
+
+// Unit is unit of metric.
+type Unit = string
+
+// Units of metric.
+const (
+	UnitNone         Unit = "None"
+	UnitSeconds      Unit = "Seconds"
+	UnitMilliseconds Unit = "Milliseconds"
+	UnitMicroseconds Unit = "Microseconds"
+	UnitBytes        Unit = "Bytes"
+	UnitKilobytes    Unit = "Kilobytes"
+	UnitMegabytes    Unit = "Megabytes"
+	UnitCount        Unit = "Count"
+	UnitPercent      Unit = "Percent"
+	UnitCountSecond  Unit = "Count/Second"
+)
+
+// Metric returns prefix of metric for EMFHook.
+//	log.Printf("%s request done", awsopt.Metric("Latency", 12.5, awsopt.UnitMilliseconds))
+func Metric(name string, value float64, unit Unit) string {
+	v := strconv.FormatFloat(value, 'g', -1, 64)
+	if unit != "" {
+		v += " " + unit
+	}
+	return "[" + MetricPrefix + name + ":" + v + "]"
+}
+
+type emfMetric struct {
+	Name string `json:"Name"`
+	Unit Unit   `json:"Unit,omitempty"`
+}
+
+type emfDirective struct {
+	Namespace  string      `json:"Namespace"`
+	Dimensions [][]string  `json:"Dimensions"`
+	Metrics    []emfMetric `json:"Metrics"`
+}
+
+type emfMetadata struct {
+	Timestamp         int64          `json:"Timestamp"`
+	CloudWatchMetrics []emfDirective `json:"CloudWatchMetrics"`
+}
+
+// EMFHook converts metric fields to Embedded Metric Format.
+// Metric fields are fields which key starts with MetricPrefix and which value is "<number>[ <unit>]".
+// dimensions are field names used as dimension if they exist in the message.
+func EMFHook(namespace string, dimensions ...string) logplug.Hook {
+	return func(enc logplug.Encoder) logplug.Encoder {
+		return logplug.EncoderFunc(func(p *logplug.Plug, m *logplug.MessageElement) error {
+			var metrics []emfMetric
+			for key, v := range m.Elements() {
+				if !strings.HasPrefix(key, MetricPrefix) {
+					continue
+				}
+				s, ok := v.(string)
+				if !ok {
+					continue
+				}
+				var unit Unit
+				if index := strings.IndexByte(s, ' '); index != -1 {
+					s, unit = s[:index], strings.TrimSpace(s[index+1:])
+				}
+				value, err := strconv.ParseFloat(s, 64)
+				if err != nil {
+					continue
+				}
+
+				name := key[len(MetricPrefix):]
+				delete(m.Elements(), key)
+				m.Set(name, value)
+				metrics = append(metrics, emfMetric{Name: name, Unit: unit})
+			}
+			if len(metrics) == 0 {
+				return enc.Encode(p, m)
+			}
+			sort.Slice(metrics, func(i, j int) bool { return metrics[i].Name < metrics[j].Name })
+
+			dims := make([]string, 0, len(dimensions))
+			for _, dim := range dimensions {
+				if _, ok := m.Elements()[dim]; ok {
+					dims = append(dims, dim)
+				}
+			}
+
+			t := m.GetTime(p.TimestampField())
+			if t.IsZero() {
+				t = time.Now()
+			}
+			m.Set("_aws", emfMetadata{
+				Timestamp: t.UnixNano() / int64(time.Millisecond),
+				CloudWatchMetrics: []emfDirective{{
+					Namespace:  namespace,
+					Dimensions: [][]string{dims},
+					Metrics:    metrics,
+				}},
+			})
+			return enc.Encode(p, m)
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package awsopt
+
+import (
+	"log"
+
+	"github.com/komem3/logplug"
+)
+
+// LogFlags is expected log flag.
+// should set this value to log.
+//	log.SetFlags(awsopt.LogFlags)
+var LogFlags = log.Ldate | log.Ltime | log.Lmicroseconds | log.LUTC | log.Lshortfile
+
+const levelField = "level"
+
+// DefaultLevelConfig is a config according to the log levels of Lambda.
+var DefaultLevelConfig = logplug.LevelConfig{
+	Levels:  []string{"TRACE", "DEBUG", "INFO", "WARN", "ERROR", "FATAL"},
+	Default: "INFO",
+	Alias: map[string]string{
+		"DBG":      "DEBUG",
+		"WARNING":  "WARN",
+		"ERR":      "ERROR",
+		"CRITICAL": "FATAL",
+	},
+	Field: levelField,
+}
+
+// NewAWSOptions provides options for CloudWatch Logs and Lambda JSON logging.
+// conf will modify awsopt.DefaultLevelConfig.
+//
+// This assumes that awsopt.LogFlags has been set for log.
+//	log.SetFlags(awsopt.LogFlags)
+//
+func NewAWSOptions(minLevel logplug.Level) []logplug.Option {
+	conf := DefaultLevelConfig
+	conf.Min = minLevel
+	return []logplug.Option{
+		logplug.LogFlag(LogFlags),
+		logplug.Hooks(
+			logplug.LevelHook(conf),
+			TraceHook(),
+		),
+	}
+}
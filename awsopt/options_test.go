@@ -0,0 +1,94 @@
+package awsopt_test
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"regexp"
+	"testing"
+
+	"github.com/komem3/logplug"
+	"github.com/komem3/logplug/awsopt"
+	"github.com/komem3/logplug/internal/testenv"
+)
+
+const traceHeader = "Root=1-5759e988-bd862e3fe1be46a994272793;Parent=53995c3f42cd8ad8;Sampled=1"
+
+func TestNewAWSOptions(t *testing.T) {
+	testenv.Setenv(t, awsopt.TraceIDEnv, traceHeader)
+
+	for _, tt := range []struct {
+		name   string
+		prefix string
+		hooks  []logplug.Hook
+		want   string
+	}{
+		{
+			name: "[DBG]ignore",
+			want: `^$`,
+		},
+		{
+			name: "[WARNING]lambda format",
+			want: `^{"level":"WARN","location":"options_test\.go:[0-9]+","message":"lambda format","timestamp":"[0-9]{4}-[0-9]{2}-[0-9]{2}T[0-9]{2}:[0-9]{2}:[0-9]{2}\.[0-9]{0,6}Z","xray_trace_id":"1-5759e988-bd862e3fe1be46a994272793"}\n$`,
+		},
+		{
+			name: "prefix trace id", prefix: "[xray_trace_id:1-00000000-000000000000000000000001]",
+			want: `"xray_trace_id":"1-00000000-000000000000000000000001"}`,
+		},
+		{
+			name:   "emf",
+			prefix: "[service:api]" + awsopt.Metric("Latency", 12.5, awsopt.UnitMilliseconds) + awsopt.Metric("Count", 1, ""),
+			hooks:  []logplug.Hook{awsopt.EMFHook("App", "service", "missing")},
+			want:   `^{"Count":1,"Latency":12.5,"_aws":{"Timestamp":[0-9]+,"CloudWatchMetrics":\[{"Namespace":"App","Dimensions":\[\["service"\]\],"Metrics":\[{"Name":"Count"},{"Name":"Latency","Unit":"Milliseconds"}\]}\]},"level":"INFO",.*"service":"api",`,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			opts := append(awsopt.NewAWSOptions("INFO"), logplug.Hooks(tt.hooks...))
+			log.New(logplug.NewJSONPlug(&buf, opts...), tt.prefix, awsopt.LogFlags).
+				Print(tt.name)
+
+			match, err := regexp.Match(tt.want, buf.Bytes())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !match {
+				t.Errorf("mismatch output\ngot:  %swant: %s", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestTracePrefix(t *testing.T) {
+	testenv.Setenv(t, awsopt.TraceIDEnv, "")
+
+	for _, tt := range []struct {
+		name string
+		ctx  context.Context
+		want string
+	}{
+		{
+			name: "empty",
+			ctx:  context.Background(),
+			want: "",
+		},
+		{
+			name: "trace header",
+			ctx:  awsopt.ContextWithTraceHeader(context.Background(), traceHeader),
+			want: "[xray_trace_id:1-5759e988-bd862e3fe1be46a994272793]",
+		},
+		{
+			name: "invalid header",
+			ctx:  awsopt.ContextWithTraceHeader(context.Background(), "Parent=53995c3f42cd8ad8"),
+			want: "",
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			if got := awsopt.TracePrefix(tt.ctx); got != tt.want {
+				t.Errorf("mismatch prefix\ngot:  %s\nwant: %s", got, tt.want)
+			}
+		})
+	}
+}
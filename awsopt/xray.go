@@ -0,0 +1,92 @@
+package awsopt
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	"github.com/komem3/logplug"
+)
+
+// TraceIDEnv is environment variable of X-Ray trace header.
+// Lambda sets this value for each invocation.
+const TraceIDEnv = "_X_AMZN_TRACE_ID"
+
+// TraceIDField is field name of X-Ray trace id.
+const TraceIDField = "xray_trace_id"
+
+// lambdaTraceKey is context key used by github.com/aws/aws-lambda-go.
+const lambdaTraceKey = "x-amzn-trace-id"
+
+type traceHeaderKey struct{}
+
+// TraceHeader is parsed X-Ray trace header.
+//	Root=1-5759e988-bd862e3fe1be46a994272793;Parent=53995c3f42cd8ad8;Sampled=1
+type TraceHeader struct {
+	Root    string
+	Parent  string
+	Sampled bool
+}
+
+// ParseTraceHeader parses X-Ray trace header.
+func ParseTraceHeader(header string) (TraceHeader, bool) {
+	var h TraceHeader
+	for _, part := range strings.Split(header, ";") {
+		index := strings.IndexByte(part, '=')
+		if index == -1 {
+			continue
+		}
+		switch v := strings.TrimSpace(part[index+1:]); strings.TrimSpace(part[:index]) {
+		case "Root":
+			h.Root = v
+		case "Parent":
+			h.Parent = v
+		case "Sampled":
+			h.Sampled = v == "1"
+		}
+	}
+	return h, h.Root != ""
+}
+
+// ContextWithTraceHeader returns a copy of ctx with X-Ray trace header.
+func ContextWithTraceHeader(ctx context.Context, header string) context.Context {
+	return context.WithValue(ctx, traceHeaderKey{}, header)
+}
+
+// TraceHeaderFromContext returns X-Ray trace header in ctx.
+// This also finds the header set by aws-lambda-go and falls back to _X_AMZN_TRACE_ID.
+func TraceHeaderFromContext(ctx context.Context) string {
+	if h, ok := ctx.Value(traceHeaderKey{}).(string); ok && h != "" {
+		return h
+	}
+	if h, ok := ctx.Value(lambdaTraceKey).(string); ok && h != "" {
+		return h
+	}
+	return os.Getenv(TraceIDEnv)
+}
+
+// TracePrefix returns prefix of X-Ray trace id in ctx.
+//	l := log.New(log.Writer(), awsopt.TracePrefix(ctx)+log.Prefix(), log.Flags())
+func TracePrefix(ctx context.Context) string {
+	h, ok := ParseTraceHeader(TraceHeaderFromContext(ctx))
+	if !ok {
+		return ""
+	}
+	return "[" + TraceIDField + ":" + h.Root + "]"
+}
+
+// TraceHook adds X-Ray trace id from _X_AMZN_TRACE_ID.
+// The trace id that has already been set by prefix is not overwritten.
+func TraceHook() logplug.Hook {
+	return func(enc logplug.Encoder) logplug.Encoder {
+		return logplug.EncoderFunc(func(p *logplug.Plug, m *logplug.MessageElement) error {
+			if m.GetString(TraceIDField) != "" {
+				return enc.Encode(p, m)
+			}
+			if h, ok := ParseTraceHeader(os.Getenv(TraceIDEnv)); ok {
+				m.Set(TraceIDField, h.Root)
+			}
+			return enc.Encode(p, m)
+		})
+	}
+}
@@ -0,0 +1,14 @@
+/*
+Package azureopt implements options for Azure Monitor and Application Insights logging.
+This option is created by referring to the following.
+	https://learn.microsoft.com/azure/azure-monitor/containers/container-insights-logs-schema
+	https://learn.microsoft.com/azure/azure-monitor/app/data-model-complete
+	https://learn.microsoft.com/azure/azure-monitor/app/distributed-trace-data
+
+Usage:
+	log.SetOutput(logplug.NewJSONPlug(os.Stdout, azureopt.NewAzureOptions("Information")...))
+	log.SetFlags(azureopt.LogFlags)
+	log.Print("[traceparent:00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01][WARN] slow request")
+	// output: {"level":"Warning","location":"main.go:12","message":"slow request","operation_Id":"4bf92f3577b34da6a3ce929d0e0e4736","operation_ParentId":"00f067aa0ba902b7","severityLevel":2,"timestamp":"2006-01-02T15:04:05.999999Z"}
+*/
+package azureopt
@@ -0,0 +1,112 @@
+package azureopt
+
+import (
+	"log"
+
+	"github.com/komem3/logplug"
+	"github.com/komem3/logplug/internal/traceparent"
+)
+
+// LogFlags is expected log flag.
+// should set this value to log.
+//	log.SetFlags(azureopt.LogFlags)
+var LogFlags = log.Ldate | log.Ltime | log.Lmicroseconds | log.LUTC | log.Lshortfile
+
+const levelField = "level"
+
+// Field names of Application Insights.
+const (
+	SeverityLevelField     = "severityLevel"
+	OperationIDField       = "operation_Id"
+	OperationParentIDField = "operation_ParentId"
+)
+
+// TraceParentField is field name of W3C traceparent.
+var TraceParentField = traceparent.Header
+
+// SeverityLevel is severityLevel of Application Insights.
+type SeverityLevel int
+
+// SeverityLevel values.
+const (
+	SeverityVerbose     SeverityLevel = 0
+	SeverityInformation SeverityLevel = 1
+	SeverityWarning     SeverityLevel = 2
+	SeverityError       SeverityLevel = 3
+	SeverityCritical    SeverityLevel = 4
+)
+
+// SeverityLevels maps level to SeverityLevel.
+var SeverityLevels = map[logplug.Level]SeverityLevel{
+	"Verbose":     SeverityVerbose,
+	"Information": SeverityInformation,
+	"Warning":     SeverityWarning,
+	"Error":       SeverityError,
+	"Critical":    SeverityCritical,
+}
+
+// DefaultLevelConfig is a config according to SeverityLevel of Application Insights.
+var DefaultLevelConfig = logplug.LevelConfig{
+	Levels:  []string{"Verbose", "Information", "Warning", "Error", "Critical"},
+	Default: "Information",
+	Alias: map[string]string{
+		"DBG":      "Verbose",
+		"DEBUG":    "Verbose",
+		"INFO":     "Information",
+		"WARN":     "Warning",
+		"WARNING":  "Warning",
+		"ERR":      "Error",
+		"ERROR":    "Error",
+		"CRITICAL": "Critical",
+		"FATAL":    "Critical",
+	},
+	Field: levelField,
+}
+
+// SeverityLevelHook adds severityLevel mapped from the level.
+func SeverityLevelHook() logplug.Hook {
+	return func(enc logplug.Encoder) logplug.Encoder {
+		return logplug.EncoderFunc(func(p *logplug.Plug, m *logplug.MessageElement) error {
+			if level, ok := SeverityLevels[m.GetString(levelField)]; ok {
+				m.Set(SeverityLevelField, level)
+			}
+			return enc.Encode(p, m)
+		})
+	}
+}
+
+// OperationHook converts the traceparent field to operation_Id and operation_ParentId.
+func OperationHook() logplug.Hook {
+	return func(enc logplug.Encoder) logplug.Encoder {
+		return logplug.EncoderFunc(func(p *logplug.Plug, m *logplug.MessageElement) error {
+			if tp, ok := traceparent.Parse(m.GetString(TraceParentField)); ok {
+				delete(m.Elements(), TraceParentField)
+				m.Set(OperationIDField, tp.TraceID)
+				m.Set(OperationParentIDField, tp.SpanID)
+			}
+			return enc.Encode(p, m)
+		})
+	}
+}
+
+// NewAzureOptions provides options for Azure Monitor logging.
+// conf will modify azureopt.DefaultLevelConfig.
+//
+// This assumes that azureopt.LogFlags has been set for log.
+//	log.SetFlags(azureopt.LogFlags)
+//
+func NewAzureOptions(minLevel logplug.Level) []logplug.Option {
+	conf := DefaultLevelConfig
+	conf.Min = minLevel
+	if alias, ok := conf.Alias[minLevel]; ok {
+		conf.Min = alias
+	}
+	return []logplug.Option{
+		logplug.LogFlag(LogFlags),
+		logplug.Hooks(
+			logplug.LevelHook(conf),
+			SeverityLevelHook(),
+			OperationHook(),
+		),
+	}
+}
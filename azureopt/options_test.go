@@ -0,0 +1,54 @@
+package azureopt_test
+
+import (
+	"bytes"
+	"log"
+	"regexp"
+	"testing"
+
+	"github.com/komem3/logplug"
+	"github.com/komem3/logplug/azureopt"
+)
+
+func TestNewAzureOptions(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		prefix string
+		want   string
+	}{
+		{
+			name: "[DBG]ignore",
+			want: `^$`,
+		},
+		{
+			name: "[ERR]severity level",
+			want: `^{"level":"Error","location":"options_test\.go:[0-9]+","message":"severity level","severityLevel":3,"timestamp":"[0-9]{4}-[0-9]{2}-[0-9]{2}T[0-9]{2}:[0-9]{2}:[0-9]{2}\.[0-9]{0,6}Z"}\n$`,
+		},
+		{
+			name:   "traceparent",
+			prefix: "[traceparent:00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01]",
+			want:   `"operation_Id":"4bf92f3577b34da6a3ce929d0e0e4736","operation_ParentId":"00f067aa0ba902b7","severityLevel":1,`,
+		},
+		{
+			name: "invalid traceparent", prefix: "[traceparent:00-xyz]",
+			want: `"severityLevel":1,"timestamp":"[^"]+","traceparent":"00-xyz"}`,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+			log.New(logplug.NewJSONPlug(&buf, azureopt.NewAzureOptions("INFO")...), tt.prefix, azureopt.LogFlags).
+				Print(tt.name)
+
+			match, err := regexp.Match(tt.want, buf.Bytes())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !match {
+				t.Errorf("mismatch output\ngot:  %swant: %s", buf.String(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,19 @@
+//go:build go1.18
+// +build go1.18
+
+package logplug
+
+import "runtime/debug"
+
+func vcsFields(info *debug.BuildInfo) map[string]interface{} {
+	fields := make(map[string]interface{}, 2)
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			fields["vcs.revision"] = setting.Value
+		case "vcs.modified":
+			fields["vcs.modified"] = setting.Value == "true"
+		}
+	}
+	return fields
+}
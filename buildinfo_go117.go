@@ -0,0 +1,10 @@
+//go:build !go1.18
+// +build !go1.18
+
+package logplug
+
+import "runtime/debug"
+
+func vcsFields(info *debug.BuildInfo) map[string]interface{} {
+	return nil
+}
@@ -0,0 +1,67 @@
+package logplug
+
+import (
+	"path"
+	"strconv"
+
+	"github.com/komem3/logplug/internal/caller"
+)
+
+// CallerConfig is option of Caller.
+type CallerConfig struct {
+	// Skip is the number of frames skipped after the frames of runtime, log and logplug.
+	// Set 1 when log is called from a wrapper function.
+	Skip int
+	// ShortFile uses the base name of the file like log.Lshortfile.
+	ShortFile bool
+	// FunctionField is field name of function. Default is "function".
+	FunctionField string
+	// PackageField is field name of package. Default is "package".
+	PackageField string
+	// FileField is field name of file. If empty, the file isn't set separately from the location.
+	FileField string
+	// LineField is field name of line as int. If empty, the line isn't set separately from the location.
+	LineField string
+}
+
+// Caller captures the caller of log with runtime.Callers.
+// The location field is set to "file:line" even if LogFlag omits log.Lshortfile or log.Llongfile,
+// and the function and package fields are added.
+//	{"function":"main.main","location":"/src/main.go:10","message":"message","package":"main"}
+//
+// The file and the line are also set separately by FileField and LineField.
+//	logplug.Caller(logplug.CallerConfig{FileField: "file", LineField: "line"})
+//	// {"file":"/src/main.go","function":"main.main","line":10,"location":"/src/main.go:10","message":"message","package":"main"}
+func Caller(config CallerConfig) Option {
+	if config.FunctionField == "" {
+		config.FunctionField = "function"
+	}
+	if config.PackageField == "" {
+		config.PackageField = "package"
+	}
+	return func(p *Plug) {
+		p.callerConfig = &config
+	}
+}
+
+func (p *Plug) setCaller(m *MessageElement) {
+	frames := m.Frames()
+	if len(frames) <= p.callerConfig.Skip {
+		return
+	}
+	frame := frames[p.callerConfig.Skip]
+
+	file := frame.File
+	if p.callerConfig.ShortFile {
+		file = path.Base(file)
+	}
+	m.Set(p.locationField, file+":"+strconv.Itoa(frame.Line))
+	m.Set(p.callerConfig.FunctionField, frame.Function)
+	m.Set(p.callerConfig.PackageField, caller.Package(frame.Function))
+	if p.callerConfig.FileField != "" {
+		m.Set(p.callerConfig.FileField, file)
+	}
+	if p.callerConfig.LineField != "" {
+		m.Set(p.callerConfig.LineField, frame.Line)
+	}
+}
@@ -0,0 +1,108 @@
+package logplug_test
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/komem3/logplug"
+)
+
+func wrapLog(l *log.Logger, msg string) {
+	l.Print(msg)
+}
+
+func TestCaller(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		flag   int
+		option logplug.CallerConfig
+		print  func(l *log.Logger, msg string)
+		want   string
+	}{
+		{
+			name:  "without file flag",
+			print: func(l *log.Logger, msg string) { l.Print(msg) },
+			want:  `{"function":"github.com/komem3/logplug_test.TestCaller.func1","location":"[[:graph:]]+/caller_test\.go:[0-9]+","message":"without file flag","package":"github.com/komem3/logplug_test"}`,
+		},
+		{
+			name:   "short file",
+			flag:   log.Lshortfile,
+			option: logplug.CallerConfig{ShortFile: true, FunctionField: "func", PackageField: "pkg"},
+			print:  func(l *log.Logger, msg string) { l.Print(msg) },
+			want:   `{"func":"github.com/komem3/logplug_test.TestCaller.func2","location":"caller_test\.go:[0-9]+","message":"short file","pkg":"github.com/komem3/logplug_test"}`,
+		},
+		{
+			name:   "wrapper",
+			option: logplug.CallerConfig{Skip: 1, ShortFile: true},
+			print:  wrapLog,
+			want:   `{"function":"github.com/komem3/logplug_test.TestCaller.func4","location":"caller_test\.go:[0-9]+","message":"wrapper","package":"github.com/komem3/logplug_test"}`,
+		},
+		{
+			name:   "file and line",
+			option: logplug.CallerConfig{ShortFile: true, FileField: "file", LineField: "line"},
+			print:  func(l *log.Logger, msg string) { l.Print(msg) },
+			want:   `{"file":"caller_test\.go","function":"github.com/komem3/logplug_test.TestCaller.func3","line":[0-9]+,"location":"caller_test\.go:[0-9]+","message":"file and line","package":"github.com/komem3/logplug_test"}`,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+			tt.print(log.New(logplug.NewJSONPlug(&buf,
+				logplug.LogFlag(tt.flag),
+				logplug.Caller(tt.option),
+			), "", tt.flag), tt.name)
+
+			match, err := regexp.MatchString("^"+tt.want+"$", strings.TrimRight(buf.String(), "\n"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !match {
+				t.Errorf("mismatch output\ngot:  %swant: %s", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONPlug_ColonInFile(t *testing.T) {
+	for _, tt := range []struct {
+		msg  string
+		want string
+	}{
+		{
+			msg:  "C:/src/main.go:10: message",
+			want: `{"location":"C:/src/main.go:10","message":"message"}`,
+		},
+		{
+			msg:  "/src/a:b.go:10: key: value",
+			want: `{"location":"/src/a:b.go:10","message":"key: value"}`,
+		},
+	} {
+		var buf bytes.Buffer
+		if _, err := logplug.NewJSONPlug(&buf, logplug.LogFlag(log.Llongfile)).Write([]byte(tt.msg)); err != nil {
+			t.Fatal(err)
+		}
+		if strings.TrimRight(buf.String(), "\n") != tt.want {
+			t.Errorf("mismatch output\ngot:  %swant: %s", buf.String(), tt.want)
+		}
+	}
+}
+
+func TestCaller_multiWriter(t *testing.T) {
+	var buf, tee bytes.Buffer
+	log.New(io.MultiWriter(&tee, logplug.NewJSONPlug(&buf, logplug.Caller(logplug.CallerConfig{}))), "", 0).Print("tee")
+
+	want := `{"function":"github.com/komem3/logplug_test.TestCaller_multiWriter","location":"[[:graph:]]+/caller_test\.go:[0-9]+","message":"tee","package":"github.com/komem3/logplug_test"}`
+	match, err := regexp.MatchString("^"+want+"$", strings.TrimRight(buf.String(), "\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !match {
+		t.Errorf("mismatch output\ngot:  %swant: %s", buf.String(), want)
+	}
+}
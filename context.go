@@ -0,0 +1,62 @@
+package logplug
+
+import (
+	"context"
+	"log"
+)
+
+type fieldsKey struct{}
+
+// WithFields returns a copy of ctx with the key-value pairs.
+// The pairs are added to the fields of the parent context.
+//	ctx = logplug.WithFields(ctx, "request_id", id, "user_id", user.ID)
+//	logplug.FromContext(ctx).Print("[INFO]request")
+func WithFields(ctx context.Context, kv ...interface{}) context.Context {
+	parent, _ := ctx.Value(fieldsKey{}).([]field)
+	fs := make([]field, 0, len(parent)+(len(kv)+1)/2)
+	fs = append(fs, parent...)
+	fs = append(fs, fields(kv)...)
+	return context.WithValue(ctx, fieldsKey{}, fs)
+}
+
+// FromContext returns a logger that writes to the output of the standard logger with the fields of ctx.
+// If the output is Plug, the fields are handed directly to the Plug and hooks can get ctx by MessageElement.Context.
+// Otherwise the fields are written as the prefix like "[key:value]".
+func FromContext(ctx context.Context) *log.Logger {
+	fs, _ := ctx.Value(fieldsKey{}).([]field)
+	return newFieldLogger(ctx, log.Writer(), log.Prefix(), log.Flags(), fs)
+}
+
+// ContextExtractor extracts a field from ctx.
+type ContextExtractor func(ctx context.Context) (key string, value interface{}, ok bool)
+
+// ContextValue returns a ContextExtractor that extracts the value of ctxKey as field key.
+//	logplug.ContextHook(logplug.ContextValue("request_id", requestIDKey{}))
+func ContextValue(key string, ctxKey interface{}) ContextExtractor {
+	return func(ctx context.Context) (string, interface{}, bool) {
+		v := ctx.Value(ctxKey)
+		return key, v, v != nil
+	}
+}
+
+// ContextHook adds the fields extracted from the context of the logger created by FromContext.
+// The fields that have already been set are not overwritten.
+func ContextHook(extractors ...ContextExtractor) Hook {
+	return func(enc Encoder) Encoder {
+		return EncoderFunc(func(p *Plug, m *MessageElement) error {
+			if m.ctx == nil {
+				return enc.Encode(p, m)
+			}
+			for _, extract := range extractors {
+				key, v, ok := extract(m.ctx)
+				if !ok {
+					continue
+				}
+				if _, exist := m.elements[key]; !exist {
+					m.elements[key] = v
+				}
+			}
+			return enc.Encode(p, m)
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package logplug_test
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/komem3/logplug"
+)
+
+type requestIDKey struct{}
+
+func setStandardLogger(t *testing.T, w *bytes.Buffer, prefix string) {
+	out, p, flags := log.Writer(), log.Prefix(), log.Flags()
+	t.Cleanup(func() {
+		log.SetOutput(out)
+		log.SetPrefix(p)
+		log.SetFlags(flags)
+	})
+	log.SetOutput(w)
+	log.SetPrefix(prefix)
+	log.SetFlags(0)
+}
+
+func TestFromContext(t *testing.T) {
+	var buf bytes.Buffer
+	setStandardLogger(t, &buf, "")
+	log.SetOutput(logplug.NewJSONPlug(&buf, logplug.Hooks(
+		logplug.LevelHook(logplug.LevelConfig{Levels: []logplug.Level{"INFO"}}),
+		logplug.ContextHook(
+			logplug.ContextValue("request_id", requestIDKey{}),
+			logplug.ContextValue("missing", "missing"),
+		),
+	)))
+
+	ctx := context.WithValue(context.Background(), requestIDKey{}, "req-1")
+	logplug.FromContext(ctx).Print("[INFO]no fields")
+
+	ctx = logplug.WithFields(ctx, "user_id", 100)
+	ctx = logplug.WithFields(ctx, "admin", true, "request_id", "override")
+	logplug.FromContext(ctx).Print("[INFO]with fields")
+
+	log.Print("[INFO]standard logger")
+
+	want := []string{
+		`{"level":"INFO","message":"no fields","request_id":"req-1"}`,
+		`{"admin":true,"level":"INFO","message":"with fields","request_id":"override","user_id":100}`,
+		`{"level":"INFO","message":"standard logger"}`,
+	}
+	got := strings.TrimRight(buf.String(), "\n")
+	if got != strings.Join(want, "\n") {
+		t.Errorf("mismatch output\ngot:\n%s\nwant:\n%s", got, strings.Join(want, "\n"))
+	}
+}
+
+func TestFromContext_plainWriter(t *testing.T) {
+	var buf bytes.Buffer
+	setStandardLogger(t, &buf, "[app:api]")
+
+	ctx := logplug.WithFields(context.Background(), "user_id", 100)
+	logplug.FromContext(ctx).Print("[INFO]message")
+
+	want := "[user_id:100][app:api][INFO]message\n"
+	if buf.String() != want {
+		t.Errorf("mismatch output\ngot:  %swant: %s", buf.String(), want)
+	}
+}
+
+func TestFromContext_withDedupHook(t *testing.T) {
+	var buf bytes.Buffer
+	setStandardLogger(t, &buf, "")
+	plug := logplug.NewJSONPlug(&buf, logplug.Hooks(
+		logplug.DedupHook(logplug.DedupConfig{Window: time.Hour}),
+		logplug.ContextHook(logplug.ContextValue("rid", requestIDKey{})),
+	))
+	log.SetOutput(plug)
+
+	ctx := context.WithValue(context.Background(), requestIDKey{}, "req-1")
+	logplug.FromContext(ctx).Print("message")
+	logplug.FromContext(ctx).Print("message")
+	if err := plug.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := regexp.MustCompile(`^\{"message":"message","rid":"req-1"\}\n` +
+		`\{"first_seen":"[^"]+","last_seen":"[^"]+","message":"message","repeat_count":1,"rid":"req-1"\}\n$`)
+	if !want.MatchString(buf.String()) {
+		t.Errorf("mismatch output\ngot:  %swant: %s", buf.String(), want)
+	}
+}
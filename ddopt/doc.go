@@ -0,0 +1,14 @@
+/*
+Package ddopt implements options for Datadog logging.
+This option is created by referring to the following.
+	https://docs.datadoghq.com/logs/log_configuration/attributes_naming_convention/
+	https://docs.datadoghq.com/tracing/other_telemetry/connect_logs_and_traces/go/
+	https://docs.datadoghq.com/getting_started/tagging/unified_service_tagging/
+
+Usage:
+	log.SetOutput(logplug.NewJSONPlug(os.Stdout, ddopt.NewDatadogOptions("info")...))
+	log.SetFlags(ddopt.LogFlags)
+	log.Print("[traceparent:00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01][WARN] slow request")
+	// output: {"dd.env":"prod","dd.service":"api","dd.span_id":"67667974448284343","dd.trace_id":"11803532876627986230","dd.version":"1.0.0","logger.file_name":"/app/main.go","logger.line":12,"logger.name":"main","message":"slow request","status":"warn","timestamp":"2006-01-02T15:04:05.999999Z"}
+*/
+package ddopt
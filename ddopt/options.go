@@ -0,0 +1,184 @@
+package ddopt
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/komem3/logplug"
+	"github.com/komem3/logplug/internal/caller"
+	"github.com/komem3/logplug/internal/traceparent"
+)
+
+// LogFlags is expected log flag.
+// should set this value to log.
+//	log.SetFlags(ddopt.LogFlags)
+var LogFlags = log.Ldate | log.Ltime | log.Lmicroseconds | log.LUTC | log.Llongfile
+
+const levelField = "status"
+
+// Field names of trace correlation.
+const (
+	TraceIDField = "dd.trace_id"
+	SpanIDField  = "dd.span_id"
+)
+
+// TraceIDFields and SpanIDFields are field names converted to Datadog ids.
+var (
+	TraceIDFields    = []string{"trace_id", "traceId"}
+	SpanIDFields     = []string{"span_id", "spanId"}
+	TraceParentField = traceparent.Header
+)
+
+// DefaultLevelConfig is a config according to the status of Datadog.
+var DefaultLevelConfig = logplug.LevelConfig{
+	Levels:  []string{"debug", "info", "notice", "warn", "error", "critical", "alert", "emergency"},
+	Default: "info",
+	Alias: map[string]string{
+		"DBG":       "debug",
+		"DEBUG":     "debug",
+		"INFO":      "info",
+		"NOTICE":    "notice",
+		"WARN":      "warn",
+		"WARNING":   "warn",
+		"ERR":       "error",
+		"ERROR":     "error",
+		"CRITICAL":  "critical",
+		"ALERT":     "alert",
+		"EMERGENCY": "emergency",
+	},
+	Field: levelField,
+}
+
+// ConvertTraceID converts a hex trace id to Datadog trace id.
+// 128-bit W3C trace id is truncated to the lower 64 bits.
+func ConvertTraceID(id string) (string, bool) {
+	if !traceparent.ValidTraceID(id) && !traceparent.ValidSpanID(id) {
+		return "", false
+	}
+	return hexToDecimal(id[len(id)-16:])
+}
+
+// ConvertSpanID converts a hex span id to Datadog span id.
+func ConvertSpanID(id string) (string, bool) {
+	if !traceparent.ValidSpanID(id) {
+		return "", false
+	}
+	return hexToDecimal(id)
+}
+
+func hexToDecimal(id string) (string, bool) {
+	n, err := strconv.ParseUint(id, 16, 64)
+	if err != nil {
+		return "", false
+	}
+	return strconv.FormatUint(n, 10), true
+}
+
+// TraceHook converts trace_id, span_id and traceparent to dd.trace_id and dd.span_id.
+func TraceHook() logplug.Hook {
+	return func(enc logplug.Encoder) logplug.Encoder {
+		return logplug.EncoderFunc(func(p *logplug.Plug, m *logplug.MessageElement) error {
+			if tp, ok := traceparent.Parse(m.GetString(TraceParentField)); ok {
+				delete(m.Elements(), TraceParentField)
+				traceID, _ := ConvertTraceID(tp.TraceID)
+				spanID, _ := ConvertSpanID(tp.SpanID)
+				m.Set(TraceIDField, traceID)
+				m.Set(SpanIDField, spanID)
+			}
+			for _, field := range TraceIDFields {
+				if id, ok := ConvertTraceID(m.GetString(field)); ok {
+					delete(m.Elements(), field)
+					m.Set(TraceIDField, id)
+				}
+			}
+			for _, field := range SpanIDFields {
+				if id, ok := ConvertSpanID(m.GetString(field)); ok {
+					delete(m.Elements(), field)
+					m.Set(SpanIDField, id)
+				}
+			}
+			return enc.Encode(p, m)
+		})
+	}
+}
+
+// ServiceHook adds dd.service, dd.env and dd.version from DD_SERVICE, DD_ENV and DD_VERSION.
+// The environment variables are read when the hook is created.
+func ServiceHook() logplug.Hook {
+	tags := make(map[string]string, 3)
+	for field, env := range map[string]string{
+		"dd.service": "DD_SERVICE",
+		"dd.env":     "DD_ENV",
+		"dd.version": "DD_VERSION",
+	} {
+		if v := os.Getenv(env); v != "" {
+			tags[field] = v
+		}
+	}
+	return func(enc logplug.Encoder) logplug.Encoder {
+		return logplug.EncoderFunc(func(p *logplug.Plug, m *logplug.MessageElement) error {
+			for field, v := range tags {
+				if _, ok := m.Elements()[field]; !ok {
+					m.Set(field, v)
+				}
+			}
+			return enc.Encode(p, m)
+		})
+	}
+}
+
+// LocationModifyHook convert the value of location in log to logger.file_name, logger.line and logger.name.
+// logger.name is the package of the log call site. It's set only when the call stack matches the location.
+func LocationModifyHook() logplug.Hook {
+	return func(enc logplug.Encoder) logplug.Encoder {
+		return logplug.EncoderFunc(func(p *logplug.Plug, m *logplug.MessageElement) error {
+			location := m.GetString(p.LocationField())
+			if location == "" {
+				return enc.Encode(p, m)
+			}
+			delete(m.Elements(), p.LocationField())
+
+			file := location
+			if index := strings.LastIndexByte(location, ':'); index != -1 {
+				if line, err := strconv.Atoi(location[index+1:]); err == nil {
+					file = location[:index]
+					m.Set("logger.line", line)
+
+					for _, frame := range m.Frames() {
+						if frame.Line == line && strings.HasSuffix(frame.File, file) {
+							m.Set("logger.name", caller.Package(frame.Function))
+							break
+						}
+					}
+				}
+			}
+			m.Set("logger.file_name", file)
+			return enc.Encode(p, m)
+		})
+	}
+}
+
+// NewDatadogOptions provides options for Datadog logging.
+// conf will modify ddopt.DefaultLevelConfig.
+//
+// This assumes that ddopt.LogFlags has been set for log.
+//	log.SetFlags(ddopt.LogFlags)
+//
+func NewDatadogOptions(minLevel logplug.Level) []logplug.Option {
+	conf := DefaultLevelConfig
+	conf.Min = minLevel
+	if alias, ok := conf.Alias[minLevel]; ok {
+		conf.Min = alias
+	}
+	return []logplug.Option{
+		logplug.LogFlag(LogFlags),
+		logplug.Hooks(
+			logplug.LevelHook(conf),
+			TraceHook(),
+			ServiceHook(),
+			LocationModifyHook(),
+		),
+	}
+}
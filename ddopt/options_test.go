@@ -0,0 +1,62 @@
+package ddopt_test
+
+import (
+	"bytes"
+	"log"
+	"regexp"
+	"testing"
+
+	"github.com/komem3/logplug"
+	"github.com/komem3/logplug/ddopt"
+	"github.com/komem3/logplug/internal/testenv"
+)
+
+func TestNewDatadogOptions(t *testing.T) {
+	testenv.Setenv(t, "DD_SERVICE", "api")
+	testenv.Setenv(t, "DD_ENV", "prod")
+	testenv.Setenv(t, "DD_VERSION", "")
+
+	for _, tt := range []struct {
+		name   string
+		prefix string
+		want   string
+	}{
+		{
+			name: "[DBG]ignore",
+			want: `^$`,
+		},
+		{
+			name: "[WARNING]status",
+			want: `^{"dd.env":"prod","dd.service":"api","logger.file_name":"[[:graph:]]+/options_test\.go","logger.line":[0-9]+,"logger.name":"github.com/komem3/logplug/ddopt_test","message":"status","status":"warn","timestamp":"[0-9]{4}-[0-9]{2}-[0-9]{2}T[0-9]{2}:[0-9]{2}:[0-9]{2}\.[0-9]{0,6}Z"}\n$`,
+		},
+		{
+			name:   "traceparent",
+			prefix: "[traceparent:00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01]",
+			want:   `"dd.span_id":"67667974448284343","dd.trace_id":"11803532876627986230",`,
+		},
+		{
+			name:   "trace id",
+			prefix: "[trace_id:4bf92f3577b34da6a3ce929d0e0e4736][span_id:00f067aa0ba902b7]",
+			want:   `"dd.span_id":"67667974448284343","dd.trace_id":"11803532876627986230",`,
+		},
+		{
+			name: "invalid trace id", prefix: "[trace_id:xyz]",
+			want: `"trace_id":"xyz"}`,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			log.New(logplug.NewJSONPlug(&buf, ddopt.NewDatadogOptions("INFO")...), tt.prefix, ddopt.LogFlags).
+				Print(tt.name)
+
+			match, err := regexp.Match(tt.want, buf.Bytes())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !match {
+				t.Errorf("mismatch output\ngot:  %swant: %s", buf.String(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,180 @@
+package logplug
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DedupConfig is option of DedupHook.
+type DedupConfig struct {
+	// Window is max duration of collapsing the same messages from the first one. Default is 1s.
+	Window time.Duration
+	// LevelField is field name of level compared with the message. Default is "level".
+	LevelField string
+	// Fields are field names compared in addition to the level and the message.
+	Fields []string
+
+	// CountField is field name of the number of repeated messages. Default is "repeat_count".
+	CountField string
+	// FirstSeenField is field name of time of the first message. Default is "first_seen".
+	FirstSeenField string
+	// LastSeenField is field name of time of the last message. Default is "last_seen".
+	LastSeenField string
+
+	// Clock returns current time. Default is time.Now.
+	Clock func() time.Time
+}
+
+type deduplicator struct {
+	conf DedupConfig
+	enc  Encoder
+
+	mu        sync.Mutex
+	plug      *Plug
+	seen      bool
+	key       string
+	firstSeen time.Time
+	// pending is a copy of the first repeated message and count is the number of the repeated messages.
+	pending  *MessageElement
+	count    int
+	lastSeen time.Time
+	timer    *time.Timer
+	err      error
+}
+
+// DedupHook collapses repeated consecutive messages that have the same level, message and Fields.
+// The first message is written immediately, and the following same messages within Window are counted.
+// When the Window expires, a different message arrives or Plug.Close is called,
+// a summary of the repeated messages is emitted with the count and the times.
+//	{"level":"ERR","message":"connection refused"}
+//	{"level":"ERR","message":"connection refused","repeat_count":1199,"first_seen":"...","last_seen":"..."}
+//
+// repeat_count doesn't include the first message, and first_seen is the time of the first message.
+// The error of the delayed summary is returned by the next Encode or Close.
+// DedupHook should be set after LevelHook to compare the level.
+func DedupHook(config DedupConfig) Hook {
+	if config.Window <= 0 {
+		config.Window = time.Second
+	}
+	if config.LevelField == "" {
+		config.LevelField = "level"
+	}
+	if config.CountField == "" {
+		config.CountField = "repeat_count"
+	}
+	if config.FirstSeenField == "" {
+		config.FirstSeenField = "first_seen"
+	}
+	if config.LastSeenField == "" {
+		config.LastSeenField = "last_seen"
+	}
+	if config.Clock == nil {
+		config.Clock = time.Now
+	}
+
+	return func(enc Encoder) Encoder {
+		return &deduplicator{
+			conf: config,
+			enc:  enc,
+		}
+	}
+}
+
+// Encode implements Encoder.
+func (d *deduplicator) Encode(p *Plug, m *MessageElement) error {
+	key := d.dedupKey(p, m)
+	now := d.conf.Clock()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.seen && d.key == key && now.Sub(d.firstSeen) < d.conf.Window {
+		if d.pending == nil {
+			d.hold(m, d.conf.Window-now.Sub(d.firstSeen))
+		}
+		d.count++
+		d.lastSeen = now
+		return d.takeErr()
+	}
+
+	d.flush()
+	d.plug = p
+	d.seen = true
+	d.key = key
+	d.firstSeen = now
+	if err := d.enc.Encode(p, m); err != nil {
+		return err
+	}
+	return d.takeErr()
+}
+
+// hold copies m as the summary and flushes it after wait. d.mu must be held.
+func (d *deduplicator) hold(m *MessageElement, wait time.Duration) {
+	pending := newMessageElement()
+	for k, v := range m.Elements() {
+		pending.Set(k, v)
+	}
+	pending.ctx = m.ctx
+	pending.errorID = m.errorID
+	m.capture()
+	pending.pcs = append(pending.pcs, m.pcs...)
+	d.pending = pending
+	d.timer = time.AfterFunc(wait, func() {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		if d.pending == pending {
+			d.flush()
+		}
+	})
+}
+
+// Close emits the summary of the repeated messages.
+func (d *deduplicator) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.flush()
+	return d.takeErr()
+}
+
+func (d *deduplicator) dedupKey(p *Plug, m *MessageElement) string {
+	var b strings.Builder
+	b.WriteString(m.GetString(d.conf.LevelField))
+	b.WriteByte(0)
+	b.WriteString(m.GetString(p.MessageField()))
+	for _, field := range d.conf.Fields {
+		b.WriteByte(0)
+		if v, ok := m.Elements()[field]; ok {
+			fmt.Fprint(&b, v)
+		}
+	}
+	return b.String()
+}
+
+// flush emits the summary of the repeated messages. d.mu must be held.
+func (d *deduplicator) flush() {
+	if d.pending == nil {
+		return
+	}
+	d.timer.Stop()
+
+	m := d.pending
+	d.pending = nil
+	defer m.release()
+
+	m.Set(d.conf.CountField, d.count)
+	m.Set(d.conf.FirstSeenField, d.firstSeen)
+	m.Set(d.conf.LastSeenField, d.lastSeen)
+	d.count = 0
+	if err := d.enc.Encode(d.plug, m); err != nil && d.err == nil {
+		d.err = err
+	}
+}
+
+func (d *deduplicator) takeErr() error {
+	err := d.err
+	d.err = nil
+	return err
+}
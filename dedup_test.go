@@ -0,0 +1,103 @@
+package logplug_test
+
+import (
+	"bytes"
+	"log"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/komem3/logplug"
+)
+
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestDedupHook(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)}
+
+	var buf bytes.Buffer
+	plug := logplug.NewJSONPlug(&buf, logplug.Hooks(
+		logplug.LevelHook(logplug.LevelConfig{Levels: []logplug.Level{"INFO", "ERR"}}),
+		logplug.DedupHook(logplug.DedupConfig{
+			Window: time.Hour,
+			Fields: []string{"host"},
+			Clock:  clock.Now,
+		}),
+	))
+	l := log.New(plug, "", 0)
+
+	for i := 0; i < 3; i++ {
+		l.Print("[ERR]connection refused")
+		clock.Add(time.Second)
+	}
+	l.Print("[INFO]connection refused")
+	l.Print("[host:a][ERR]connection refused")
+	l.Print("[host:b][ERR]connection refused")
+	l.Print("[host:b][ERR]connection refused")
+
+	// a new group starts after the window.
+	clock.Add(time.Hour)
+	l.Print("[host:b][ERR]connection refused")
+
+	if err := plug.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		`{"level":"ERR","message":"connection refused"}`,
+		`{"first_seen":"2021-01-01T00:00:00Z","last_seen":"2021-01-01T00:00:02Z","level":"ERR","message":"connection refused","repeat_count":2}`,
+		`{"level":"INFO","message":"connection refused"}`,
+		`{"host":"a","level":"ERR","message":"connection refused"}`,
+		`{"host":"b","level":"ERR","message":"connection refused"}`,
+		`{"first_seen":"2021-01-01T00:00:03Z","host":"b","last_seen":"2021-01-01T00:00:03Z","level":"ERR","message":"connection refused","repeat_count":1}`,
+		`{"host":"b","level":"ERR","message":"connection refused"}`,
+	}
+	got := strings.TrimRight(buf.String(), "\n")
+	if got != strings.Join(want, "\n") {
+		t.Errorf("mismatch output\ngot:\n%s\nwant:\n%s", got, strings.Join(want, "\n"))
+	}
+}
+
+func TestDedupHook_window(t *testing.T) {
+	var buf syncBuffer
+	plug := logplug.NewJSONPlug(&buf, logplug.Hooks(
+		logplug.DedupHook(logplug.DedupConfig{Window: 10 * time.Millisecond}),
+	))
+	defer plug.Close()
+	l := log.New(plug, "", 0)
+
+	l.Print("connection refused")
+	l.Print("connection refused")
+
+	// the first message is written without waiting for the window.
+	first := "{\"message\":\"connection refused\"}\n"
+	if got := buf.String(); got != first {
+		t.Errorf("mismatch output\ngot:  %swant: %s", got, first)
+	}
+
+	for i := 0; i < 100 && strings.Count(buf.String(), "\n") < 2; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	want := regexp.MustCompile(`^` + regexp.QuoteMeta(first) +
+		`\{"first_seen":"[^"]+","last_seen":"[^"]+","message":"connection refused","repeat_count":1\}\n$`)
+	if got := buf.String(); !want.MatchString(got) {
+		t.Errorf("mismatch output\ngot:  %swant: %s", got, want)
+	}
+}
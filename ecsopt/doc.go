@@ -0,0 +1,14 @@
+/*
+Package ecsopt implements options for Elastic Common Schema (ECS) logging.
+This option is created by referring to the following.
+	https://www.elastic.co/guide/en/ecs/current/ecs-base.html
+	https://www.elastic.co/guide/en/ecs/current/ecs-log.html
+	https://www.elastic.co/guide/en/ecs/current/ecs-error.html
+
+Usage:
+	log.SetOutput(logplug.NewJSONPlug(os.Stdout, ecsopt.NewECSOptions("INFO")...))
+	log.SetFlags(ecsopt.LogFlags)
+	log.Print("[error:timeout][ERR] request failed")
+	// output: {"@timestamp":"2006-01-02T15:04:05.999999Z","ecs.version":"8.11.0","error.message":"timeout","log.level":"error","log.origin.file.line":12,"log.origin.file.name":"/app/main.go","message":"request failed"}
+*/
+package ecsopt
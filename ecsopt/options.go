@@ -0,0 +1,162 @@
+package ecsopt
+
+import (
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/komem3/logplug"
+)
+
+// LogFlags is expected log flag.
+// should set this value to log.
+//	log.SetFlags(ecsopt.LogFlags)
+var LogFlags = log.Ldate | log.Ltime | log.Lmicroseconds | log.LUTC | log.Llongfile
+
+// Version is value of ecs.version.
+const Version = "8.11.0"
+
+const levelField = "log.level"
+
+// DefaultLevelConfig is a config according to the syslog severity names used by ECS.
+var DefaultLevelConfig = logplug.LevelConfig{
+	Levels:  []string{"debug", "info", "notice", "warning", "error", "critical", "alert", "emergency"},
+	Default: "info",
+	Alias: map[string]string{
+		"DBG":       "debug",
+		"DEBUG":     "debug",
+		"INFO":      "info",
+		"NOTICE":    "notice",
+		"WARN":      "warning",
+		"WARNING":   "warning",
+		"ERR":       "error",
+		"ERROR":     "error",
+		"CRITICAL":  "critical",
+		"ALERT":     "alert",
+		"EMERGENCY": "emergency",
+	},
+	Field: levelField,
+}
+
+// ErrorFields maps field names to ECS error fields.
+var ErrorFields = map[string]string{
+	"error": "error.message",
+	"stack": "error.stack_trace",
+}
+
+// ErrorTypeField is field name mapped to error.type.
+// The field is mapped only if the message has the error field of ErrorFields,
+// so the other "type" fields are kept.
+var ErrorTypeField = "type"
+
+// VersionHook adds ecs.version.
+func VersionHook() logplug.Hook {
+	return func(enc logplug.Encoder) logplug.Encoder {
+		return logplug.EncoderFunc(func(p *logplug.Plug, m *logplug.MessageElement) error {
+			m.Set("ecs.version", Version)
+			return enc.Encode(p, m)
+		})
+	}
+}
+
+// TimestampModifyHook convert the key of timestamp in log to @timestamp.
+func TimestampModifyHook() logplug.Hook {
+	return func(enc logplug.Encoder) logplug.Encoder {
+		return logplug.EncoderFunc(func(p *logplug.Plug, m *logplug.MessageElement) error {
+			if t, ok := m.Elements()[p.TimestampField()]; ok {
+				delete(m.Elements(), p.TimestampField())
+				m.Set("@timestamp", t)
+			}
+			return enc.Encode(p, m)
+		})
+	}
+}
+
+// LocationModifyHook convert the value of location in log to log.origin.file.name and log.origin.file.line.
+func LocationModifyHook() logplug.Hook {
+	return func(enc logplug.Encoder) logplug.Encoder {
+		return logplug.EncoderFunc(func(p *logplug.Plug, m *logplug.MessageElement) error {
+			location := m.GetString(p.LocationField())
+			if location == "" {
+				return enc.Encode(p, m)
+			}
+			delete(m.Elements(), p.LocationField())
+
+			index := strings.LastIndexByte(location, ':')
+			if index == -1 {
+				m.Set("log.origin.file.name", location)
+				return enc.Encode(p, m)
+			}
+			if line, err := strconv.Atoi(location[index+1:]); err == nil {
+				m.Set("log.origin.file.name", location[:index])
+				m.Set("log.origin.file.line", line)
+			} else {
+				m.Set("log.origin.file.name", location)
+			}
+			return enc.Encode(p, m)
+		})
+	}
+}
+
+// ErrorModifyHook convert the fields of ErrorFields and ErrorTypeField to ECS error fields.
+// logplug.ErrorValue set by logplug.ErrorValueHook is flattened to error.message, error.type and error.stack_trace.
+func ErrorModifyHook() logplug.Hook {
+	return func(enc logplug.Encoder) logplug.Encoder {
+		return logplug.EncoderFunc(func(p *logplug.Plug, m *logplug.MessageElement) error {
+			if _, ok := m.Elements()["error"]; ok {
+				if v, ok := m.Elements()[ErrorTypeField]; ok {
+					delete(m.Elements(), ErrorTypeField)
+					m.Set("error.type", v)
+				}
+			}
+			for from, to := range ErrorFields {
+				if v, ok := m.Elements()[from]; ok {
+					delete(m.Elements(), from)
+					m.Set(to, v)
+				}
+			}
+			switch v := m.Elements()["error.message"].(type) {
+			case logplug.ErrorValue:
+				setErrorValue(m, v)
+			case error:
+				setErrorValue(m, logplug.NewErrorValue(v))
+			}
+			return enc.Encode(p, m)
+		})
+	}
+}
+
+// setErrorValue sets v to the ECS error fields. The fields set explicitly are kept.
+func setErrorValue(m *logplug.MessageElement, v logplug.ErrorValue) {
+	m.Set("error.message", v.Message)
+	if _, ok := m.Elements()["error.type"]; !ok {
+		m.Set("error.type", v.Type)
+	}
+	if _, ok := m.Elements()["error.stack_trace"]; !ok && v.Stack != "" {
+		m.Set("error.stack_trace", v.Stack)
+	}
+}
+
+// NewECSOptions provides options for ECS logging.
+// conf will modify ecsopt.DefaultLevelConfig.
+//
+// This assumes that ecsopt.LogFlags has been set for log.
+//	log.SetFlags(ecsopt.LogFlags)
+//
+func NewECSOptions(minLevel logplug.Level) []logplug.Option {
+	conf := DefaultLevelConfig
+	conf.Min = minLevel
+	if alias, ok := conf.Alias[minLevel]; ok {
+		conf.Min = alias
+	}
+	return []logplug.Option{
+		logplug.LogFlag(LogFlags),
+		logplug.Hooks(
+			logplug.LevelHook(conf),
+			VersionHook(),
+			TimestampModifyHook(),
+			LocationModifyHook(),
+			ErrorModifyHook(),
+		),
+	}
+}
@@ -0,0 +1,71 @@
+package ecsopt_test
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"regexp"
+	"testing"
+
+	"github.com/komem3/logplug"
+	"github.com/komem3/logplug/ecsopt"
+)
+
+func TestNewECSOptions(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		prefix string
+		want   string
+	}{
+		{
+			name: "[DBG]ignore",
+			want: `^$`,
+		},
+		{
+			name: "default level",
+			want: `^{"@timestamp":"[0-9]{4}-[0-9]{2}-[0-9]{2}T[0-9]{2}:[0-9]{2}:[0-9]{2}\.[0-9]{0,6}Z","ecs.version":"8.11.0","log.level":"info","log.origin.file.line":[0-9]+,"log.origin.file.name":"[[:graph:]]+options_test\.go","message":"default level"}\n$`,
+		},
+		{
+			name: "[ERR]error fields", prefix: "[error:timeout][stack:main.go:10]",
+			want: `"error.message":"timeout","error.stack_trace":"main.go:10","log.level":"error",`,
+		},
+		{
+			name: "[ERR]error type", prefix: "[error:timeout][type:net.OpError]",
+			want: `"error.message":"timeout","error.type":"net.OpError","log.level":"error",`,
+		},
+		{
+			name: "type without error", prefix: "[type:order]",
+			want: `"message":"type without error","type":"order"}`,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+			log.New(logplug.NewJSONPlug(&buf, ecsopt.NewECSOptions("INFO")...), tt.prefix, ecsopt.LogFlags).
+				Print(tt.name)
+
+			match, err := regexp.Match(tt.want, buf.Bytes())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !match {
+				t.Errorf("mismatch output\ngot:  %swant: %s", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestErrorModifyHook_errorValue(t *testing.T) {
+	var buf bytes.Buffer
+	opts := append([]logplug.Option{logplug.Hooks(logplug.ErrorValueHook())}, ecsopt.NewECSOptions("INFO")...)
+	log.New(logplug.NewJSONPlug(&buf, opts...), "", ecsopt.LogFlags).
+		Printf("%s[ERR]failed", logplug.Err(fmt.Errorf("save: %w", io.EOF)))
+
+	want := `"error.message":"save: EOF","error.type":"*fmt.wrapError","log.level":"error",`
+	if !bytes.Contains(buf.Bytes(), []byte(want)) {
+		t.Errorf("mismatch output\ngot:  %swant: %s", buf.String(), want)
+	}
+}
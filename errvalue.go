@@ -0,0 +1,161 @@
+package logplug
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ErrorField is field name of the error written by Err.
+const ErrorField = "error"
+
+// ErrorIDField is field name of the id of the error written by Err.
+// The field is removed by Plug and used by ErrorValueHook.
+const ErrorIDField = "error_id"
+
+// errorRegistrySize is max number of errors kept for ErrorValueHook.
+// The errors are removed when ErrorValueHook looks up them, so only the errors not logged by the Plug with ErrorValueHook are evicted.
+const errorRegistrySize = 256
+
+var errorRegistry = struct {
+	sync.Mutex
+	// enabled is true after ErrorValueHook is created.
+	enabled bool
+	lastID  uint64
+	errs    map[string]error
+	order   []string
+}{
+	errs: make(map[string]error),
+}
+
+// ErrorCause is a wrapped error of ErrorValue.
+type ErrorCause struct {
+	Message string `json:"message"`
+	Type    string `json:"type"`
+}
+
+// ErrorValue is structured error converted by ErrorValueHook.
+type ErrorValue struct {
+	Message string       `json:"message"`
+	Type    string       `json:"type"`
+	Causes  []ErrorCause `json:"causes,omitempty"`
+	// Stack is the stack trace of the deepest error that implements StackTrace() like github.com/pkg/errors.
+	Stack string `json:"stack,omitempty"`
+}
+
+// Err returns the prefix of err and registers err for ErrorValueHook.
+// The prefix should be written before the level.
+//	log.Printf("%s[ERR] save failed", logplug.Err(err))
+//	// {"error":{"message":"...","type":"*fs.PathError","causes":[...]},"level":"ERR","message":"save failed"}
+//
+// After ErrorValueHook is created, the prefix has the unique id of err in ErrorIDField,
+// and ErrorValueHook converts only the error that has the id.
+// Without ErrorValueHook, the error is written as the message string.
+func Err(err error) string {
+	if err == nil {
+		return ""
+	}
+	prefix := "[" + ErrorField + ":" + prefixValueReplacer.Replace(err.Error()) + "]"
+
+	errorRegistry.Lock()
+	defer errorRegistry.Unlock()
+
+	if !errorRegistry.enabled {
+		return prefix
+	}
+	if len(errorRegistry.order) >= errorRegistrySize {
+		delete(errorRegistry.errs, errorRegistry.order[0])
+		errorRegistry.order = errorRegistry.order[1:]
+	}
+	errorRegistry.lastID++
+	id := strconv.FormatUint(errorRegistry.lastID, 10)
+	errorRegistry.errs[id] = err
+	errorRegistry.order = append(errorRegistry.order, id)
+	return prefix + "[" + ErrorIDField + ":" + id + "]"
+}
+
+// takeError returns the error of id and removes it from the registry.
+func takeError(id string) (error, bool) {
+	errorRegistry.Lock()
+	defer errorRegistry.Unlock()
+	err, ok := errorRegistry.errs[id]
+	delete(errorRegistry.errs, id)
+	return err, ok
+}
+
+// NewErrorValue converts err to ErrorValue.
+func NewErrorValue(err error) ErrorValue {
+	v := ErrorValue{
+		Message: err.Error(),
+		Type:    fmt.Sprintf("%T", err),
+		Stack:   stackTrace(err),
+	}
+	for _, cause := range unwrapAll(err) {
+		v.Causes = append(v.Causes, ErrorCause{Message: cause.Error(), Type: fmt.Sprintf("%T", cause)})
+		if stack := stackTrace(cause); stack != "" {
+			v.Stack = stack
+		}
+	}
+	return v
+}
+
+// unwrapAll returns the wrapped errors of err in depth-first order.
+func unwrapAll(err error) []error {
+	var wrapped []error
+	switch e := err.(type) {
+	case interface{ Unwrap() []error }:
+		wrapped = e.Unwrap()
+	default:
+		if cause := errors.Unwrap(err); cause != nil {
+			wrapped = []error{cause}
+		}
+	}
+
+	var causes []error
+	for _, cause := range wrapped {
+		if cause == nil {
+			continue
+		}
+		causes = append(causes, cause)
+		causes = append(causes, unwrapAll(cause)...)
+	}
+	return causes
+}
+
+// stackTrace returns the result of StackTrace() formatted by "%+v".
+func stackTrace(err error) string {
+	method := reflect.ValueOf(err).MethodByName("StackTrace")
+	if !method.IsValid() || method.Type().NumIn() != 0 || method.Type().NumOut() != 1 {
+		return ""
+	}
+	return strings.TrimLeft(fmt.Sprintf("%+v", method.Call(nil)[0].Interface()), "\n")
+}
+
+// ErrorValueHook converts the error written by Err and the typed error values to ErrorValue.
+// The typed error values are set by Logger.With or WithFields.
+//	logplug.NewLogger(l).With("error", err).Print("[ERR] save failed")
+// The error written by Err is looked up by ErrorIDField, so the error field written without Err is kept.
+func ErrorValueHook() Hook {
+	errorRegistry.Lock()
+	errorRegistry.enabled = true
+	errorRegistry.Unlock()
+
+	return func(enc Encoder) Encoder {
+		return EncoderFunc(func(p *Plug, m *MessageElement) error {
+			for key, v := range m.elements {
+				if err, ok := v.(error); ok {
+					m.elements[key] = NewErrorValue(err)
+				}
+			}
+			if m.errorID != "" {
+				if err, ok := takeError(m.errorID); ok && m.GetString(ErrorField) == prefixValueReplacer.Replace(err.Error()) {
+					m.Set(ErrorField, NewErrorValue(err))
+				}
+			}
+			return enc.Encode(p, m)
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package logplug_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"reflect"
+	"testing"
+
+	"github.com/komem3/logplug"
+)
+
+type stack []string
+
+func (s stack) Format(f fmt.State, verb rune) {
+	for _, frame := range s {
+		fmt.Fprintf(f, "\n%s", frame)
+	}
+}
+
+type stackError struct {
+	msg   string
+	stack stack
+}
+
+func (e *stackError) Error() string     { return e.msg }
+func (e *stackError) StackTrace() stack { return e.stack }
+
+func TestErrorValueHook(t *testing.T) {
+	pathErr := &fs.PathError{Op: "open", Path: "[x]", Err: fs.ErrNotExist}
+
+	for _, tt := range []struct {
+		name string
+		err  error
+		want logplug.ErrorValue
+	}{
+		{
+			name: "simple",
+			err:  errors.New("simple error"),
+			want: logplug.ErrorValue{Message: "simple error", Type: "*errors.errorString"},
+		},
+		{
+			name: "wrapped",
+			err:  fmt.Errorf("save failed: %w", pathErr),
+			want: logplug.ErrorValue{
+				Message: "save failed: open [x]: file does not exist",
+				Type:    "*fmt.wrapError",
+				Causes: []logplug.ErrorCause{
+					{Message: "open [x]: file does not exist", Type: "*fs.PathError"},
+					{Message: "file does not exist", Type: "*errors.errorString"},
+				},
+			},
+		},
+		{
+			name: "stack trace",
+			err:  fmt.Errorf("wrap: %w", &stackError{msg: "origin", stack: stack{"main.main", "runtime.main"}}),
+			want: logplug.ErrorValue{
+				Message: "wrap: origin",
+				Type:    "*fmt.wrapError",
+				Causes:  []logplug.ErrorCause{{Message: "origin", Type: "*logplug_test.stackError"}},
+				Stack:   "main.main\nruntime.main",
+			},
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+			log.New(logplug.NewJSONPlug(&buf, logplug.Hooks(
+				logplug.LevelHook(logplug.LevelConfig{Levels: []logplug.Level{"INFO", "ERR"}}),
+				logplug.ErrorValueHook(),
+			)), "", 0).Printf("%s[ERR] failed", logplug.Err(tt.err))
+
+			var got struct {
+				Level   string
+				Message string
+				Error   logplug.ErrorValue
+			}
+			if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+				t.Fatalf("unmarshal %s: %v", buf.String(), err)
+			}
+			if got.Level != "ERR" || got.Message != "failed" {
+				t.Errorf("mismatch output\ngot:  %swant: level=ERR, message=failed", buf.String())
+			}
+			if !reflect.DeepEqual(got.Error, tt.want) {
+				t.Errorf("mismatch error\ngot:  %+v\nwant: %+v", got.Error, tt.want)
+			}
+		})
+	}
+}
+
+func TestErr_withoutHook(t *testing.T) {
+	var buf bytes.Buffer
+	log.New(logplug.NewJSONPlug(&buf), "", 0).Print(logplug.Err(errors.New("line1\nline2 [x]")) + "failed")
+
+	want := `{"error":"line1 line2 (x)","message":"failed"}` + "\n"
+	if buf.String() != want {
+		t.Errorf("mismatch output\ngot:  %swant: %s", buf.String(), want)
+	}
+}
+
+func TestErrorValueHook_typedError(t *testing.T) {
+	var buf bytes.Buffer
+	logplug.NewLogger(log.New(logplug.NewJSONPlug(&buf, logplug.Hooks(
+		logplug.ErrorValueHook(),
+	)), "", 0)).With("error", errors.New("boom"), "cause", fmt.Errorf("wrap: %w", io.EOF)).Print("failed")
+
+	want := `{"cause":{"message":"wrap: EOF","type":"*fmt.wrapError","causes":[{"message":"EOF","type":"*errors.errorString"}]},"error":{"message":"boom","type":"*errors.errorString"},"message":"failed"}` + "\n"
+	if buf.String() != want {
+		t.Errorf("mismatch output\ngot:  %swant: %s", buf.String(), want)
+	}
+}
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string { return "timeout" }
+
+func TestErrorValueHook_sameMessage(t *testing.T) {
+	var buf bytes.Buffer
+	l := log.New(logplug.NewJSONPlug(&buf, logplug.Hooks(
+		logplug.ErrorValueHook(),
+	)), "", 0)
+
+	typed := logplug.Err(timeoutError{})
+	plain := logplug.Err(errors.New("timeout"))
+	l.Print(typed + "typed")
+	l.Print(plain + "plain")
+	l.Print("[error:timeout]text")
+
+	want := `{"error":{"message":"timeout","type":"logplug_test.timeoutError"},"message":"typed"}` + "\n" +
+		`{"error":{"message":"timeout","type":"*errors.errorString"},"message":"plain"}` + "\n" +
+		`{"error":"timeout","message":"text"}` + "\n"
+	if buf.String() != want {
+		t.Errorf("mismatch output\ngot:  %swant: %s", buf.String(), want)
+	}
+}
@@ -0,0 +1,8 @@
+package logplug
+
+import "runtime"
+
+// DetectModuleRoot returns the module root detected by TrimLocationHook from frame.
+func DetectModuleRoot(config TrimLocationConfig, frame runtime.Frame) string {
+	return (&locationTrimmer{conf: config}).detectRoot(frame)
+}
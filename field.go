@@ -0,0 +1,127 @@
+package logplug
+
+import (
+	"os"
+	"runtime"
+	"runtime/debug"
+	"time"
+)
+
+// FieldFunc returns value of dynamic field.
+type FieldFunc func() interface{}
+
+// StaticFieldsHook adds fields to each message.
+// The values are typed, so they are not parsed like prefix.
+// The fields that have already been set are not overwritten.
+//	logplug.StaticFieldsHook(logplug.MergeFields(
+//		logplug.ProcessFields(),
+//		logplug.BuildFields(),
+//		logplug.EnvFields("APP_ENV"),
+//	))
+func StaticFieldsHook(fields map[string]interface{}) Hook {
+	copied := make(map[string]interface{}, len(fields))
+	for key, v := range fields {
+		copied[key] = v
+	}
+	return func(enc Encoder) Encoder {
+		return EncoderFunc(func(p *Plug, m *MessageElement) error {
+			for key, v := range copied {
+				if _, ok := m.elements[key]; !ok {
+					m.elements[key] = v
+				}
+			}
+			return enc.Encode(p, m)
+		})
+	}
+}
+
+// DynamicFieldsHook adds fields computed by the functions for each message.
+// The fields that have already been set are not overwritten.
+//	logplug.DynamicFieldsHook(map[string]logplug.FieldFunc{
+//		"goroutines": logplug.NumGoroutine,
+//		"uptime":     logplug.Uptime(),
+//	})
+func DynamicFieldsHook(fields map[string]FieldFunc) Hook {
+	copied := make(map[string]FieldFunc, len(fields))
+	for key, f := range fields {
+		copied[key] = f
+	}
+	return func(enc Encoder) Encoder {
+		return EncoderFunc(func(p *Plug, m *MessageElement) error {
+			for key, f := range copied {
+				if _, ok := m.elements[key]; !ok {
+					m.elements[key] = f()
+				}
+			}
+			return enc.Encode(p, m)
+		})
+	}
+}
+
+// MergeFields merges fields into a new map.
+// The later value has priority.
+func MergeFields(fields ...map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{})
+	for _, f := range fields {
+		for key, v := range f {
+			merged[key] = v
+		}
+	}
+	return merged
+}
+
+// ProcessFields returns hostname and pid of the process.
+func ProcessFields() map[string]interface{} {
+	fields := map[string]interface{}{
+		"pid": os.Getpid(),
+	}
+	if hostname, err := os.Hostname(); err == nil {
+		fields["hostname"] = hostname
+	}
+	return fields
+}
+
+// BuildFields returns go version, main module version and vcs revision from the build info.
+//	{"go_version": "go1.17", "version": "v1.0.0", "vcs.revision": "2a1b...", "vcs.modified": false}
+// vcs fields are available since go1.18 and when built with vcs stamping.
+func BuildFields() map[string]interface{} {
+	fields := map[string]interface{}{
+		"go_version": runtime.Version(),
+	}
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return fields
+	}
+	if info.Main.Version != "" && info.Main.Version != "(devel)" {
+		fields["version"] = info.Main.Version
+	}
+	for key, v := range vcsFields(info) {
+		fields[key] = v
+	}
+	return fields
+}
+
+// EnvFields returns values of the environment variables.
+// The key is the name of the variable and the empty values are omitted.
+func EnvFields(names ...string) map[string]interface{} {
+	fields := make(map[string]interface{}, len(names))
+	for _, name := range names {
+		if v := os.Getenv(name); v != "" {
+			fields[name] = v
+		}
+	}
+	return fields
+}
+
+// NumGoroutine returns the number of goroutines.
+func NumGoroutine() interface{} {
+	return runtime.NumGoroutine()
+}
+
+// Uptime returns a FieldFunc of seconds elapsed since Uptime is called.
+func Uptime() FieldFunc {
+	start := time.Now()
+	return func() interface{} {
+		return time.Since(start).Seconds()
+	}
+}
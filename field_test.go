@@ -0,0 +1,84 @@
+package logplug_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"os"
+	"testing"
+
+	"github.com/komem3/logplug"
+	"github.com/komem3/logplug/internal/testenv"
+)
+
+func TestStaticFieldsHook(t *testing.T) {
+	testenv.Setenv(t, "LOGPLUG_TEST_ENV", "test")
+	testenv.Setenv(t, "LOGPLUG_TEST_EMPTY", "")
+
+	var buf bytes.Buffer
+	log.New(logplug.NewJSONPlug(&buf, logplug.Hooks(
+		logplug.StaticFieldsHook(logplug.MergeFields(
+			logplug.ProcessFields(),
+			logplug.BuildFields(),
+			logplug.EnvFields("LOGPLUG_TEST_ENV", "LOGPLUG_TEST_EMPTY"),
+			map[string]interface{}{"replicas": 3, "label": "static"},
+		)),
+	)), "[label:prefix]", 0).Print("static")
+
+	var got map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	hostname, _ := os.Hostname()
+	for key, want := range map[string]interface{}{
+		"pid":              float64(os.Getpid()),
+		"hostname":         hostname,
+		"LOGPLUG_TEST_ENV": "test",
+		"replicas":         float64(3),
+		"label":            "prefix",
+		"message":          "static",
+	} {
+		if got[key] != want {
+			t.Errorf("mismatch %s: got %v, want %v", key, got[key], want)
+		}
+	}
+	if _, ok := got["go_version"]; !ok {
+		t.Errorf("go_version is missing: %s", buf.String())
+	}
+	if _, ok := got["LOGPLUG_TEST_EMPTY"]; ok {
+		t.Errorf("empty env is added: %s", buf.String())
+	}
+}
+
+func TestDynamicFieldsHook(t *testing.T) {
+	var (
+		buf   bytes.Buffer
+		count int
+	)
+	l := log.New(logplug.NewJSONPlug(&buf, logplug.Hooks(
+		logplug.DynamicFieldsHook(map[string]logplug.FieldFunc{
+			"count":      func() interface{} { count++; return count },
+			"goroutines": logplug.NumGoroutine,
+			"uptime":     logplug.Uptime(),
+		}),
+	)), "", 0)
+	l.Print("first")
+	l.Print("second")
+
+	dec := json.NewDecoder(&buf)
+	for i := 1; i <= 2; i++ {
+		var got map[string]interface{}
+		if err := dec.Decode(&got); err != nil {
+			t.Fatal(err)
+		}
+		if got["count"] != float64(i) {
+			t.Errorf("mismatch count: got %v, want %d", got["count"], i)
+		}
+		if n, _ := got["goroutines"].(float64); n < 1 {
+			t.Errorf("mismatch goroutines: %v", got["goroutines"])
+		}
+		if _, ok := got["uptime"].(float64); !ok {
+			t.Errorf("mismatch uptime: %v", got["uptime"])
+		}
+	}
+}
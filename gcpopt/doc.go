@@ -2,5 +2,14 @@
 Package gcpopt implements options for GCP logging.
 This option is created by referring to the following.
 	https://cloud.google.com/logging/docs/structured-logging#special-payload-fields
+
+Special fields can be written with prefix by SpecialFieldsHook.
+SpecialFieldsHook isn't included in NewGCPOptions, so add it explicitly.
+	log.SetOutput(logplug.NewJSONPlug(os.Stdout, append(gcpopt.NewGCPOptions("INFO"), logplug.Hooks(gcpopt.SpecialFieldsHook("")))...))
+	log.Print("[httpRequest.requestMethod:GET][httpRequest.status:200][labels.env:prod] request")
+	// output: {"httpRequest":{"requestMethod":"GET","status":200},"logging.googleapis.com/labels":{"env":"prod"},"message":"request","severity":"INFO"}
+
+Outside GCP, Exporter writes entries with the Cloud Logging API.
+The fake server of the API for tests is in the loggingfake package.
 */
 package gcpopt
@@ -0,0 +1,153 @@
+package gcpopt
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/komem3/logplug"
+)
+
+// ErrorReportType is @type of error report.
+const ErrorReportType = "type.googleapis.com/google.devtools.clouderrorreporting.v1beta1.ReportedErrorEvent"
+
+// ErrorReportConfig is option of NewErrorReportHook.
+type ErrorReportConfig struct {
+	// Levels are converted to the format of an error report.
+	Levels []logplug.Level
+	// Service is serviceContext.service.
+	// Default is K_SERVICE, GAE_SERVICE or the executable name.
+	Service string
+	// Version is serviceContext.version.
+	// Default is K_REVISION or GAE_VERSION.
+	Version string
+	// DisableStackTrace disables capturing the stack trace.
+	DisableStackTrace bool
+}
+
+// ServiceContext is serviceContext of error report.
+type ServiceContext struct {
+	Service string `json:"service"`
+	Version string `json:"version,omitempty"`
+}
+
+// ReportLocation is context.reportLocation of error report.
+type ReportLocation struct {
+	FilePath     string `json:"filePath"`
+	LineNumber   int    `json:"lineNumber"`
+	FunctionName string `json:"functionName"`
+}
+
+type errorContext struct {
+	ReportLocation *ReportLocation `json:"reportLocation,omitempty"`
+}
+
+func getenv(keys ...string) string {
+	for _, key := range keys {
+		if v := os.Getenv(key); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// ErrorReportHook converts the specified levels to the format of an error report.
+//	https://cloud.google.com/error-reporting/docs/formatting-error-messages#@type
+// This is same as NewErrorReportHook(ErrorReportConfig{Levels: levels}).
+func ErrorReportHook(levels ...logplug.Level) logplug.Hook {
+	return NewErrorReportHook(ErrorReportConfig{Levels: levels})
+}
+
+// NewErrorReportHook converts the specified levels to the format of an error report.
+// It adds @type, serviceContext, context.reportLocation and stack_trace.
+// The stack trace is captured at the log call site and written in the format of Go panic.
+//	https://cloud.google.com/error-reporting/docs/formatting-error-messages
+func NewErrorReportHook(conf ErrorReportConfig) logplug.Hook {
+	alartLevel := make(map[logplug.Level]bool, len(conf.Levels))
+	for _, level := range conf.Levels {
+		alartLevel[level] = true
+	}
+	if conf.Service == "" {
+		conf.Service = getenv("K_SERVICE", "GAE_SERVICE")
+	}
+	if conf.Service == "" {
+		conf.Service = filepath.Base(os.Args[0])
+	}
+	if conf.Version == "" {
+		conf.Version = getenv("K_REVISION", "GAE_VERSION")
+	}
+	service := ServiceContext{Service: conf.Service, Version: conf.Version}
+
+	return func(enc logplug.Encoder) logplug.Encoder {
+		return logplug.EncoderFunc(func(p *logplug.Plug, m *logplug.MessageElement) error {
+			if _, ok := alartLevel[m.GetString(levelField)]; !ok {
+				return enc.Encode(p, m)
+			}
+
+			m.AddString("@type", ErrorReportType)
+			m.Set("serviceContext", service)
+
+			location := parseLocation(m.GetString(p.LocationField()))
+			if !conf.DisableStackTrace {
+				frames := m.Frames()
+				if location == nil && len(frames) > 0 {
+					location = &ReportLocation{FilePath: frames[0].File, LineNumber: frames[0].Line}
+				}
+				if location != nil {
+					if i := matchFrame(frames, location.FilePath, location.LineNumber); i != -1 {
+						location.FunctionName = frames[i].Function
+						frames = frames[i:]
+					}
+				}
+				m.Set("stack_trace", formatStack(m.GetString(p.MessageField()), frames))
+			}
+			if location != nil {
+				m.Set("context", errorContext{ReportLocation: location})
+			}
+			return enc.Encode(p, m)
+		})
+	}
+}
+
+// matchFrame returns the index of the first frame at file and line.
+// file may be the short file name. If no frame matches, matchFrame returns -1.
+func matchFrame(frames []runtime.Frame, file string, line int) int {
+	for i, frame := range frames {
+		if frame.Line == line && strings.HasSuffix(frame.File, file) {
+			return i
+		}
+	}
+	return -1
+}
+
+func parseLocation(location string) *ReportLocation {
+	index := strings.LastIndexByte(location, ':')
+	if index == -1 {
+		return nil
+	}
+	line, err := strconv.Atoi(location[index+1:])
+	if err != nil {
+		return nil
+	}
+	return &ReportLocation{FilePath: location[:index], LineNumber: line}
+}
+
+// formatStack formats message and frames in the format of Go panic.
+func formatStack(message string, frames []runtime.Frame) string {
+	var b strings.Builder
+	b.WriteString(message)
+	b.WriteString("\n\ngoroutine 1 [running]:\n")
+	for _, frame := range frames {
+		b.WriteString(frame.Function)
+		b.WriteString("(...)\n\t")
+		b.WriteString(frame.File)
+		b.WriteByte(':')
+		b.WriteString(strconv.Itoa(frame.Line))
+		b.WriteString(" +0x")
+		b.WriteString(strconv.FormatUint(uint64(frame.PC-frame.Entry), 16))
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
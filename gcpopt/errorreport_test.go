@@ -0,0 +1,166 @@
+package gcpopt_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/komem3/logplug"
+	"github.com/komem3/logplug/gcpopt"
+	"github.com/komem3/logplug/internal/testenv"
+)
+
+type errorReport struct {
+	Type           string `json:"@type"`
+	ServiceContext struct {
+		Service string `json:"service"`
+		Version string `json:"version"`
+	} `json:"serviceContext"`
+	Context struct {
+		ReportLocation struct {
+			FilePath     string `json:"filePath"`
+			LineNumber   int    `json:"lineNumber"`
+			FunctionName string `json:"functionName"`
+		} `json:"reportLocation"`
+	} `json:"context"`
+	StackTrace string `json:"stack_trace"`
+}
+
+func TestNewErrorReportHook(t *testing.T) {
+	testenv.Setenv(t, "K_SERVICE", "env-service")
+	testenv.Setenv(t, "K_REVISION", "env-revision")
+
+	for _, tt := range []struct {
+		name        string
+		conf        gcpopt.ErrorReportConfig
+		message     string
+		wantService string
+		wantVersion string
+		wantStack   string
+	}{
+		{
+			name:    "not report",
+			conf:    gcpopt.ErrorReportConfig{Levels: []logplug.Level{"ALERT"}},
+			message: "[ERROR] not report",
+		},
+		{
+			name:        "env service",
+			conf:        gcpopt.ErrorReportConfig{Levels: []logplug.Level{"ALERT"}},
+			message:     "[ALERT] env service",
+			wantService: "env-service",
+			wantVersion: "env-revision",
+			wantStack:   `^env service\n\ngoroutine 1 \[running\]:\ngithub.com/komem3/logplug/gcpopt_test.TestNewErrorReportHook.func1\(...\)\n\t[[:graph:]]+/errorreport_test.go:[0-9]+ \+0x[0-9a-f]+\n`,
+		},
+		{
+			name:        "config service",
+			conf:        gcpopt.ErrorReportConfig{Levels: []logplug.Level{"ALERT"}, Service: "api", Version: "v1", DisableStackTrace: true},
+			message:     "[ALERT] config service",
+			wantService: "api",
+			wantVersion: "v1",
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			conf := gcpopt.DefaultLevelConfig
+			log.New(logplug.NewJSONPlug(&buf,
+				logplug.LogFlag(log.Llongfile),
+				logplug.Hooks(logplug.LevelHook(conf), gcpopt.NewErrorReportHook(tt.conf)),
+			), "", log.Llongfile).Print(tt.message)
+
+			var got errorReport
+			if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantService == "" {
+				if got.Type != "" {
+					t.Errorf("unexpected error report: %s", buf.String())
+				}
+				return
+			}
+
+			if got.Type != gcpopt.ErrorReportType {
+				t.Errorf("mismatch @type: %s", got.Type)
+			}
+			if got.ServiceContext.Service != tt.wantService || got.ServiceContext.Version != tt.wantVersion {
+				t.Errorf("mismatch serviceContext: %+v", got.ServiceContext)
+			}
+			if match, _ := regexp.MatchString(`/errorreport_test\.go$`, got.Context.ReportLocation.FilePath); !match || got.Context.ReportLocation.LineNumber == 0 {
+				t.Errorf("mismatch reportLocation: %+v", got.Context.ReportLocation)
+			}
+			if match, _ := regexp.MatchString(tt.wantStack, got.StackTrace); tt.wantStack != "" && !match {
+				t.Errorf("mismatch stack_trace\ngot:  %s\nwant: %s", got.StackTrace, tt.wantStack)
+			}
+			if tt.wantStack == "" && got.StackTrace != "" {
+				t.Errorf("unexpected stack_trace: %s", got.StackTrace)
+			}
+		})
+	}
+}
+
+func alert(l *log.Logger, msg string) {
+	_ = l.Output(2, "[ALERT] "+msg)
+}
+
+func TestNewErrorReportHook_wrapper(t *testing.T) {
+	var buf bytes.Buffer
+	l := log.New(logplug.NewJSONPlug(&buf,
+		logplug.LogFlag(log.Lshortfile),
+		logplug.Hooks(logplug.LevelHook(gcpopt.DefaultLevelConfig), gcpopt.ErrorReportHook("ALERT")),
+	), "", log.Lshortfile)
+	alert(l, "wrapper")
+
+	var got errorReport
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	want := "github.com/komem3/logplug/gcpopt_test.TestNewErrorReportHook_wrapper"
+	if got.Context.ReportLocation.FilePath != "errorreport_test.go" || got.Context.ReportLocation.FunctionName != want {
+		t.Errorf("mismatch reportLocation: %+v", got.Context.ReportLocation)
+	}
+	if wantStack := "wrapper\n\ngoroutine 1 [running]:\n" + want + "(...)\n"; !strings.HasPrefix(got.StackTrace, wantStack) {
+		t.Errorf("mismatch stack_trace\ngot:  %s\nwant: %s", got.StackTrace, wantStack)
+	}
+}
+
+func closePlug(p *logplug.Plug) error {
+	return p.Close()
+}
+
+func TestNewErrorReportHook_dedup(t *testing.T) {
+	var buf bytes.Buffer
+	p := logplug.NewJSONPlug(&buf,
+		logplug.LogFlag(log.Llongfile),
+		logplug.Hooks(
+			logplug.LevelHook(gcpopt.DefaultLevelConfig),
+			logplug.DedupHook(logplug.DedupConfig{Window: time.Hour}),
+			gcpopt.ErrorReportHook("ALERT"),
+		),
+	)
+	l := log.New(p, "", log.Llongfile)
+	for i := 0; i < 2; i++ {
+		l.Print("[ALERT] repeated")
+	}
+	if err := closePlug(p); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	if len(lines) != 2 {
+		t.Fatalf("mismatch output\ngot:  %s", buf.String())
+	}
+	var first, summary errorReport
+	if err := json.Unmarshal(lines[0], &first); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(lines[1], &summary); err != nil {
+		t.Fatal(err)
+	}
+	if summary.Context.ReportLocation != first.Context.ReportLocation || summary.StackTrace != first.StackTrace {
+		t.Errorf("mismatch summary\ngot:  %+v\nwant: %+v", summary, first)
+	}
+}
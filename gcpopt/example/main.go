@@ -2,7 +2,6 @@ package main
 
 import (
 	"context"
-	"fmt"
 	"log"
 	"net/http"
 	"os"
@@ -36,21 +35,13 @@ func init() {
 		}
 		projectID = p
 	}
-}
-
-func NewLog(ctx context.Context) *log.Logger {
-	l := log.New(log.Writer(), log.Prefix(), log.Flags())
-	if metadata.OnGCE() {
-		span := trace.SpanFromContext(ctx).SpanContext()
-		l.SetPrefix(fmt.Sprintf("[logging.googleapis.com/trace:projects/%s/traces/%s][logging.googleapis.com/spanId:%s][logging.googleapis.com/trace_sampled:%t]%s",
-			projectID, span.TraceID(), span.SpanID(), span.IsSampled(), l.Prefix()))
-	}
-	return l
+	gcpopt.SetProjectID(projectID)
 }
 
 func main() {
 	if metadata.OnGCE() {
-		log.SetOutput(logplug.NewJSONPlug(os.Stderr, gcpopt.NewGCPOptions("DEBUG")...))
+		opts := append(gcpopt.NewGCPOptions("DEBUG"), logplug.Hooks(gcpopt.SpecialFieldsHook(projectID)))
+		log.SetOutput(logplug.NewJSONPlug(os.Stderr, opts...))
 		log.SetFlags(gcpopt.LogFlags)
 	}
 
@@ -74,7 +65,7 @@ func main() {
 
 	mux := http.NewServeMux()
 	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-		l := NewLog(r.Context())
+		l := spanLogger(r.Context())
 
 		l.Printf("[DBG] request serve %s", r.RequestURI)
 		l.Print("default level is info")
@@ -84,8 +75,25 @@ func main() {
 		w.Write([]byte("hello world"))
 	})
 
-	otelHandler := otelhttp.NewHandler(mux, "Hello", otelhttp.WithPropagators(propagator.New()))
+	// gcpopt.Middleware runs inside otelhttp, so the request log has the trace of the incoming headers
+	// and the application logs use the server span of otelhttp by spanLogger.
+	otelHandler := otelhttp.NewHandler(gcpopt.Middleware(mux), "Hello", otelhttp.WithPropagators(propagator.New()))
 
 	log.Printf("[DBG] listen %s", port)
 	log.Panicf("[CRITICAL] %v", http.ListenAndServe(":"+port, otelHandler))
 }
+
+// spanLogger returns a logger that has the trace prefix of the OTel span in ctx.
+// If ctx has no span, the logger of gcpopt.Middleware is returned.
+func spanLogger(ctx context.Context) *log.Logger {
+	sc := trace.SpanFromContext(ctx).SpanContext()
+	if !sc.IsValid() {
+		return gcpopt.LoggerFromContext(ctx)
+	}
+	ctx = gcpopt.ContextWithTrace(ctx, gcpopt.TraceContext{
+		TraceID: sc.TraceID().String(),
+		SpanID:  sc.SpanID().String(),
+		Sampled: sc.IsSampled(),
+	})
+	return log.New(log.Writer(), gcpopt.TracePrefix(ctx)+log.Prefix(), log.Flags())
+}
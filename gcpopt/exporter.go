@@ -0,0 +1,287 @@
+package gcpopt
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/komem3/logplug"
+	"github.com/komem3/logplug/internal/batch"
+	"github.com/komem3/logplug/internal/retry"
+)
+
+// WriteEndpoint is endpoint of entries.write.
+const WriteEndpoint = "https://logging.googleapis.com/v2/entries:write"
+
+// MonitoredResource is resource of LogEntry.
+//	https://cloud.google.com/logging/docs/reference/v2/rest/v2/MonitoredResource
+type MonitoredResource struct {
+	Type   string            `json:"type"`
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// LogEntry is LogEntry of entries.write.
+//	https://cloud.google.com/logging/docs/reference/v2/rest/v2/LogEntry
+type LogEntry struct {
+	LogName        string                 `json:"logName,omitempty"`
+	Timestamp      string                 `json:"timestamp,omitempty"`
+	Severity       string                 `json:"severity,omitempty"`
+	InsertID       string                 `json:"insertId,omitempty"`
+	HTTPRequest    *HTTPRequest           `json:"httpRequest,omitempty"`
+	Labels         map[string]string      `json:"labels,omitempty"`
+	Operation      *Operation             `json:"operation,omitempty"`
+	Trace          string                 `json:"trace,omitempty"`
+	SpanID         string                 `json:"spanId,omitempty"`
+	TraceSampled   bool                   `json:"traceSampled,omitempty"`
+	SourceLocation *SourceLocation        `json:"sourceLocation,omitempty"`
+	JSONPayload    map[string]interface{} `json:"jsonPayload,omitempty"`
+}
+
+// WriteRequest is request body of entries.write.
+//	https://cloud.google.com/logging/docs/reference/v2/rest/v2/entries/write
+type WriteRequest struct {
+	LogName        string             `json:"logName,omitempty"`
+	Resource       *MonitoredResource `json:"resource,omitempty"`
+	Labels         map[string]string  `json:"labels,omitempty"`
+	Entries        []LogEntry         `json:"entries"`
+	PartialSuccess bool               `json:"partialSuccess,omitempty"`
+}
+
+// RetryableStatus are the http status codes of entries.write worth retrying.
+// These are RESOURCE_EXHAUSTED, INTERNAL, UNAVAILABLE and DEADLINE_EXCEEDED of the Cloud Logging API.
+//	https://cloud.google.com/logging/docs/reference/v2/rest#error-codes
+var RetryableStatus = []int{
+	http.StatusTooManyRequests,
+	http.StatusInternalServerError,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// ExporterConfig is option of Exporter.
+type ExporterConfig struct {
+	// Endpoint is url of entries.write. Default is WriteEndpoint.
+	Endpoint string
+	// Client is used to send requests. Default is http.DefaultClient.
+	// This client should add the credentials, e.g. the client of golang.org/x/oauth2/google.
+	Client *http.Client
+	// Headers are added to each request.
+	Headers map[string]string
+	// ProjectID is project of log name. Default is ProjectID().
+	ProjectID string
+	// LogID is id of log name. Default is "logplug".
+	LogID string
+	// Resource is monitored resource of entries. Default is "global".
+	Resource MonitoredResource
+	// Labels are common labels of entries.
+	Labels map[string]string
+
+	// BatchSize is max number of entries per request. Default is 500.
+	BatchSize int
+	// FlushInterval is interval of background flush. Default is 5s.
+	// If negative, only the full batches are sent in the background.
+	FlushInterval time.Duration
+	// Timeout is timeout of a request including retries. Default is 30s.
+	Timeout time.Duration
+	// MaxQueueSize is max number of buffered entries. Default is 16 times BatchSize.
+	// While the endpoint is slow or down, the entries exceeding MaxQueueSize are dropped
+	// and the count is reported to ErrorHandler.
+	MaxQueueSize int
+
+	// MaxRetries is max count of retry. Default is 5 and negative disables retry.
+	MaxRetries int
+	// InitialBackoff is wait time of first retry. Default is 100ms.
+	InitialBackoff time.Duration
+	// MaxBackoff is max wait time of retry. Default is 10s.
+	MaxBackoff time.Duration
+	// RetryableStatus are the http status codes retried. Default is RetryableStatus.
+	RetryableStatus []int
+
+	// ErrorHandler is called when the background send fails or the entries are dropped.
+	// Default writes the error to os.Stderr.
+	ErrorHandler func(err error)
+}
+
+// Exporter is a encoder that writes batches of LogEntry with the Cloud Logging API.
+// The batches are sent in the background, so Encode doesn't wait for the API.
+// This is useful outside GCP where stdout isn't collected.
+// Use the hooks of NewGCPOptions and SpecialFieldsHook so that special fields are converted to LogEntry fields.
+//	exporter, err := gcpopt.NewExporter(gcpopt.ExporterConfig{Client: client, LogID: "batch"})
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	defer exporter.Close()
+//	log.SetOutput(logplug.NewPlug(exporter, append(gcpopt.NewGCPOptions("INFO"), logplug.Hooks(gcpopt.SpecialFieldsHook("")))...))
+type Exporter struct {
+	conf    ExporterConfig
+	policy  retry.Policy
+	header  http.Header
+	batcher *batch.Batcher
+}
+
+// NewExporter create a new Exporter.
+// If the project id isn't set by ProjectID of conf, SetProjectID or ProjectIDEnvs, NewExporter returns an error.
+func NewExporter(conf ExporterConfig) (*Exporter, error) {
+	if conf.Endpoint == "" {
+		conf.Endpoint = WriteEndpoint
+	}
+	if conf.ProjectID == "" {
+		conf.ProjectID = ProjectID()
+	}
+	if conf.ProjectID == "" {
+		return nil, errors.New("gcpopt: project id is unknown")
+	}
+	if conf.LogID == "" {
+		conf.LogID = "logplug"
+	}
+	if conf.Resource.Type == "" {
+		conf.Resource.Type = "global"
+	}
+	if conf.BatchSize <= 0 {
+		conf.BatchSize = 500
+	}
+	if conf.RetryableStatus == nil {
+		conf.RetryableStatus = RetryableStatus
+	}
+	if conf.ErrorHandler == nil {
+		conf.ErrorHandler = func(err error) {
+			fmt.Fprintf(os.Stderr, "gcpopt: write entries: %v\n", err)
+		}
+	}
+
+	e := &Exporter{
+		conf: conf,
+		policy: retry.Policy{
+			MaxRetries:      conf.MaxRetries,
+			InitialBackoff:  conf.InitialBackoff,
+			MaxBackoff:      conf.MaxBackoff,
+			RetryableStatus: conf.RetryableStatus,
+		},
+		header: retry.JSONHeader(conf.Headers),
+	}
+	e.batcher = batch.New(batch.Config{
+		BatchSize:     conf.BatchSize,
+		FlushInterval: conf.FlushInterval,
+		Timeout:       conf.Timeout,
+		MaxQueueSize:  conf.MaxQueueSize,
+		ErrorHandler:  conf.ErrorHandler,
+	}, e.send)
+	return e, nil
+}
+
+// LogName returns log name of entries.
+func (e *Exporter) LogName() string {
+	return "projects/" + e.conf.ProjectID + "/logs/" + url.PathEscape(e.conf.LogID)
+}
+
+// NewLogEntry converts m to LogEntry.
+// The special fields are moved to LogEntry fields and the others are set to jsonPayload.
+func NewLogEntry(p *logplug.Plug, m *logplug.MessageElement) LogEntry {
+	entry := LogEntry{
+		JSONPayload: make(map[string]interface{}, len(m.Elements())),
+	}
+
+	for key, v := range m.Elements() {
+		switch key {
+		case p.TimestampField():
+			if t, ok := v.(time.Time); ok {
+				entry.Timestamp = t.Format(time.RFC3339Nano)
+				continue
+			}
+		case levelField:
+			if s, ok := v.(string); ok {
+				entry.Severity = s
+				continue
+			}
+		case InsertIDField:
+			if s, ok := v.(string); ok {
+				entry.InsertID = s
+				continue
+			}
+		case HTTPRequestField:
+			if r := structuredHTTPRequest(v); *r != (HTTPRequest{}) {
+				entry.HTTPRequest = r
+				continue
+			}
+		case LabelsField:
+			if labels, ok := v.(map[string]string); ok {
+				entry.Labels = make(map[string]string, len(labels))
+				for k, e := range labels {
+					entry.Labels[k] = e
+				}
+				continue
+			}
+		case OperationField:
+			if o := structuredOperation(v); *o != (Operation{}) {
+				entry.Operation = o
+				continue
+			}
+		case TraceField:
+			if s, ok := v.(string); ok {
+				entry.Trace = s
+				continue
+			}
+		case SpanIDField:
+			if s, ok := v.(string); ok {
+				entry.SpanID = s
+				continue
+			}
+		case TraceSampledField:
+			if b, ok := v.(bool); ok {
+				entry.TraceSampled = b
+				continue
+			}
+		case SourceLocationField:
+			if l, ok := v.(SourceLocation); ok {
+				entry.SourceLocation = &l
+				continue
+			}
+		}
+		entry.JSONPayload[key] = v
+	}
+	return entry
+}
+
+// Encode implements logplug.Encoder.
+// The entry is sent in the background when the number of entries reaches BatchSize.
+func (e *Exporter) Encode(p *logplug.Plug, m *logplug.MessageElement) error {
+	entry := NewLogEntry(p, m)
+	if entry.Trace != "" {
+		entry.Trace = TraceName(e.conf.ProjectID, entry.Trace)
+	}
+	e.batcher.Add(entry)
+	return nil
+}
+
+// Flush sends buffered entries.
+func (e *Exporter) Flush(ctx context.Context) error {
+	return e.batcher.Flush(ctx)
+}
+
+// Close stops the background flush and sends buffered entries.
+func (e *Exporter) Close() error {
+	return e.batcher.Close()
+}
+
+func (e *Exporter) send(ctx context.Context, items []interface{}) error {
+	entries := make([]LogEntry, len(items))
+	for i, item := range items {
+		entries[i] = item.(LogEntry)
+	}
+
+	body, err := json.Marshal(WriteRequest{
+		LogName:  e.LogName(),
+		Resource: &e.conf.Resource,
+		Labels:   e.conf.Labels,
+		Entries:  entries,
+	})
+	if err != nil {
+		return err
+	}
+	return e.policy.Post(ctx, e.conf.Client, e.conf.Endpoint, e.header, body)
+}
@@ -0,0 +1,106 @@
+package gcpopt_test
+
+import (
+	"log"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/komem3/logplug"
+	"github.com/komem3/logplug/gcpopt"
+	"github.com/komem3/logplug/gcpopt/loggingfake"
+	"github.com/komem3/logplug/internal/testenv"
+)
+
+func TestExporter(t *testing.T) {
+	server := loggingfake.NewServer()
+	defer server.Close()
+	server.RequireAuthorization("Bearer token")
+	server.FailNext(http.StatusInternalServerError, http.StatusTooManyRequests)
+
+	exporter, err := gcpopt.NewExporter(gcpopt.ExporterConfig{
+		Endpoint:       server.Endpoint(),
+		Headers:        map[string]string{"Authorization": "Bearer token"},
+		ProjectID:      "test-project",
+		LogID:          "batch/job",
+		Resource:       gcpopt.MonitoredResource{Type: "generic_task", Labels: map[string]string{"job": "batch"}},
+		Labels:         map[string]string{"env": "test"},
+		BatchSize:      2,
+		FlushInterval:  -1,
+		InitialBackoff: time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts := append(gcpopt.NewGCPOptions("INFO"), logplug.Hooks(gcpopt.SpecialFieldsHook("")))
+	l := log.New(logplug.NewPlug(exporter, opts...), "", gcpopt.LogFlags)
+	l.Print("[DEBUG] ignored")
+	l.Print("[trace:4bf92f3577b34da6a3ce929d0e0e4736][labels.step:1][WARN] first")
+	l.Print("[httpRequest.status:200]second")
+	l.Print("[ERROR] third")
+	if err := exporter.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	requests := server.Requests()
+	if len(requests) != 2 {
+		t.Fatalf("mismatch request count: %d", len(requests))
+	}
+	if requests[0].LogName != "projects/test-project/logs/batch%2Fjob" || requests[0].Resource.Type != "generic_task" {
+		t.Errorf("mismatch request: %+v", requests[0])
+	}
+
+	entries := server.Entries()
+	if len(entries) != 3 {
+		t.Fatalf("mismatch entry count: %d", len(entries))
+	}
+
+	first := entries[0]
+	if first.Severity != "WARNING" || first.JSONPayload["message"] != "first" ||
+		first.Trace != "projects/test-project/traces/4bf92f3577b34da6a3ce929d0e0e4736" ||
+		first.Labels["step"] != "1" || first.Labels["env"] != "test" ||
+		first.SourceLocation == nil || first.SourceLocation.Function == "" {
+		t.Errorf("mismatch first entry: %+v", first)
+	}
+	if second := entries[1]; second.Severity != "INFO" || second.HTTPRequest == nil || second.HTTPRequest.Status != 200 ||
+		second.Trace != "" {
+		t.Errorf("mismatch second entry: %+v", second)
+	}
+	if third := entries[2]; third.Severity != "ERROR" || third.JSONPayload["message"] != "third" || third.Trace != "" {
+		t.Errorf("mismatch third entry: %+v", third)
+	}
+}
+
+func TestExporter_PermanentError(t *testing.T) {
+	server := loggingfake.NewServer()
+	defer server.Close()
+	server.RequireAuthorization("Bearer token")
+
+	exporter, err := gcpopt.NewExporter(gcpopt.ExporterConfig{
+		Endpoint:      server.Endpoint(),
+		ProjectID:     "test-project",
+		FlushInterval: -1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	log.New(logplug.NewPlug(exporter), "", 0).Print("unauthorized")
+
+	if err := exporter.Close(); err == nil {
+		t.Error("expected error")
+	}
+	if len(server.Requests()) != 0 {
+		t.Error("unexpected request")
+	}
+}
+
+func TestNewExporter_unknownProject(t *testing.T) {
+	for _, env := range gcpopt.ProjectIDEnvs {
+		testenv.Setenv(t, env, "")
+	}
+
+	if _, err := gcpopt.NewExporter(gcpopt.ExporterConfig{}); err == nil {
+		t.Error("expected error")
+	}
+}
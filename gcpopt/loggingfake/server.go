@@ -0,0 +1,146 @@
+// Package loggingfake implements a local fake of the Cloud Logging entries.write API.
+// This is intended for tests of gcpopt.Exporter without network access.
+//	server := loggingfake.NewServer()
+//	defer server.Close()
+//	exporter, err := gcpopt.NewExporter(gcpopt.ExporterConfig{Endpoint: server.Endpoint(), ProjectID: "test"})
+package loggingfake
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+
+	"github.com/komem3/logplug/gcpopt"
+)
+
+// Server is a fake server of entries.write.
+type Server struct {
+	server *httptest.Server
+
+	mu         sync.Mutex
+	requests   []gcpopt.WriteRequest
+	failures   []int
+	authHeader string
+}
+
+// NewServer starts a new fake server.
+func NewServer() *Server {
+	s := &Server{}
+	s.server = httptest.NewServer(s)
+	return s
+}
+
+// Endpoint returns url of entries.write.
+func (s *Server) Endpoint() string {
+	return s.server.URL + "/v2/entries:write"
+}
+
+// Close shuts down the server.
+func (s *Server) Close() {
+	s.server.Close()
+}
+
+// RequireAuthorization makes the server reject requests without the authorization header.
+func (s *Server) RequireAuthorization(header string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.authHeader = header
+}
+
+// FailNext makes the next requests fail with the status codes in order.
+func (s *Server) FailNext(statuses ...int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures = append(s.failures, statuses...)
+}
+
+// Requests returns the received requests.
+func (s *Server) Requests() []gcpopt.WriteRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]gcpopt.WriteRequest(nil), s.requests...)
+}
+
+// Entries returns the received entries.
+// logName, resource and labels of the request are merged into each entry like the API.
+func (s *Server) Entries() []gcpopt.LogEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var entries []gcpopt.LogEntry
+	for _, req := range s.requests {
+		for _, entry := range req.Entries {
+			if entry.LogName == "" {
+				entry.LogName = req.LogName
+			}
+			if len(req.Labels) > 0 {
+				labels := make(map[string]string, len(req.Labels)+len(entry.Labels))
+				for key, v := range req.Labels {
+					labels[key] = v
+				}
+				for key, v := range entry.Labels {
+					labels[key] = v
+				}
+				entry.Labels = labels
+			}
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r.Method != http.MethodPost || !strings.HasSuffix(r.URL.Path, "/entries:write") {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	if s.authHeader != "" && r.Header.Get("Authorization") != s.authHeader {
+		writeError(w, http.StatusUnauthorized, "request had invalid authentication credentials")
+		return
+	}
+	if len(s.failures) > 0 {
+		status := s.failures[0]
+		s.failures = s.failures[1:]
+		writeError(w, status, http.StatusText(status))
+		return
+	}
+
+	var req gcpopt.WriteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if req.LogName == "" {
+		for _, entry := range req.Entries {
+			if entry.LogName == "" {
+				writeError(w, http.StatusBadRequest, "logName is required")
+				return
+			}
+		}
+	}
+	if len(req.Entries) == 0 {
+		writeError(w, http.StatusBadRequest, "entries are required")
+		return
+	}
+
+	s.requests = append(s.requests, req)
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte("{}\n"))
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error": map[string]interface{}{
+			"code":    status,
+			"message": message,
+		},
+	})
+}
@@ -0,0 +1,178 @@
+package gcpopt
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Middleware attaches a request-scoped logger to the request context and logs the request.
+// The logger has the trace prefix of the request and can be got by LoggerFromContext.
+// At the end of the request, a parent entry which has httpRequest is emitted with the same trace,
+// so the application logs are grouped under the request in Cloud Logging.
+//	http.ListenAndServe(":8080", gcpopt.Middleware(mux))
+//
+// The parent entry is written with the prefixes of httpRequest, so the output of log needs SpecialFieldsHook.
+// The severity of the parent entry is ERROR for 5xx, WARNING for 4xx and INFO for others.
+// remoteIp is the first address of X-Forwarded-For as sent by the client or the proxy,
+// so it can be spoofed unless a trusted proxy overwrites the header.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		ctx := ContextFromRequest(r)
+		l := LoggerFromContext(ctx)
+		ctx = ContextWithLogger(ctx, l)
+
+		rw := &responseRecorder{ResponseWriter: w}
+		next.ServeHTTP(rw, r.WithContext(ctx))
+
+		if rw.status == 0 {
+			rw.status = http.StatusOK
+		}
+		level := "INFO"
+		switch {
+		case rw.status >= 500:
+			level = "ERROR"
+		case rw.status >= 400:
+			level = "WARNING"
+		}
+		l.Print(requestPrefix(r, rw.status, rw.size, time.Since(start)) +
+			"[" + level + "] " + r.Method + " " + escapeURL(r.URL.Path))
+	})
+}
+
+func requestPrefix(r *http.Request, status int, size int64, latency time.Duration) string {
+	var b strings.Builder
+	field := func(key, v string) {
+		if v == "" {
+			return
+		}
+		b.WriteString("[" + HTTPRequestPrefix + key + ":" + v + "]")
+	}
+
+	field("requestMethod", r.Method)
+	field("requestUrl", escapeURL(requestURL(r)))
+	if r.ContentLength > 0 {
+		field("requestSize", strconv.FormatInt(r.ContentLength, 10))
+	}
+	field("status", strconv.Itoa(status))
+	field("responseSize", strconv.FormatInt(size, 10))
+	field("userAgent", escapePrefixValue(r.UserAgent()))
+	field("remoteIp", escapePrefixValue(remoteIP(r)))
+	field("referer", escapeURL(r.Referer()))
+	field("latency", latency.String())
+	field("protocol", r.Proto)
+	return b.String()
+}
+
+func requestURL(r *http.Request) string {
+	if r.URL.IsAbs() {
+		return r.URL.String()
+	}
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+	return scheme + "://" + r.Host + r.URL.RequestURI()
+}
+
+func remoteIP(r *http.Request) string {
+	if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+		if index := strings.IndexByte(forwarded, ','); index != -1 {
+			forwarded = forwarded[:index]
+		}
+		return strings.TrimSpace(forwarded)
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// escapeURL escapes characters that break the prefix.
+func escapeURL(s string) string {
+	return strings.NewReplacer("[", "%5B", "]", "%5D", "\n", "%0A").Replace(s)
+}
+
+// responseRecorder records status and size of response.
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	size   int64
+}
+
+func (rw *responseRecorder) WriteHeader(status int) {
+	if rw.status == 0 {
+		rw.status = status
+	}
+	rw.ResponseWriter.WriteHeader(status)
+}
+
+func (rw *responseRecorder) Write(b []byte) (int, error) {
+	if rw.status == 0 {
+		rw.status = http.StatusOK
+	}
+	n, err := rw.ResponseWriter.Write(b)
+	rw.size += int64(n)
+	return n, err
+}
+
+// Flush implements http.Flusher.
+func (rw *responseRecorder) Flush() {
+	if f, ok := rw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack implements http.Hijacker.
+// The status of the hijacked connection is recorded as 101 Switching Protocols.
+func (rw *responseRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := rw.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("gcpopt: ResponseWriter doesn't implement http.Hijacker")
+	}
+	conn, brw, err := h.Hijack()
+	if err == nil && rw.status == 0 {
+		rw.status = http.StatusSwitchingProtocols
+	}
+	return conn, brw, err
+}
+
+// ReadFrom implements io.ReaderFrom.
+func (rw *responseRecorder) ReadFrom(r io.Reader) (int64, error) {
+	if rw.status == 0 {
+		rw.status = http.StatusOK
+	}
+	var n int64
+	var err error
+	if rf, ok := rw.ResponseWriter.(io.ReaderFrom); ok {
+		n, err = rf.ReadFrom(r)
+	} else {
+		n, err = io.Copy(rw.ResponseWriter, r)
+	}
+	rw.size += n
+	return n, err
+}
+
+// Push implements http.Pusher.
+func (rw *responseRecorder) Push(target string, opts *http.PushOptions) error {
+	if p, ok := rw.ResponseWriter.(http.Pusher); ok {
+		return p.Push(target, opts)
+	}
+	return http.ErrNotSupported
+}
+
+// Unwrap returns the original ResponseWriter for http.ResponseController.
+func (rw *responseRecorder) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
@@ -0,0 +1,92 @@
+package gcpopt_test
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/komem3/logplug"
+	"github.com/komem3/logplug/gcpopt"
+)
+
+func TestMiddleware(t *testing.T) {
+	gcpopt.SetProjectID("test-project")
+	defer gcpopt.SetProjectID("")
+
+	var buf bytes.Buffer
+	out, flags, prefix := log.Writer(), log.Flags(), log.Prefix()
+	log.SetOutput(logplug.NewJSONPlug(&buf, logplug.Hooks(
+		logplug.LevelHook(gcpopt.DefaultLevelConfig),
+		gcpopt.SpecialFieldsHook(""),
+	)))
+	log.SetFlags(0)
+	log.SetPrefix("")
+	defer func() {
+		log.SetOutput(out)
+		log.SetFlags(flags)
+		log.SetPrefix(prefix)
+	}()
+
+	handler := gcpopt.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gcpopt.LoggerFromContext(r.Context()).Print("child log")
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("not found"))
+	}))
+
+	r := httptest.NewRequest("GET", "http://example.com/path?q=[1]", nil)
+	r.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	r.Header.Set("User-Agent", "test-agent")
+	r.RemoteAddr = "192.0.2.1:1234"
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+
+	trace := `"logging.googleapis.com/spanId":"00f067aa0ba902b7","logging.googleapis.com/trace":"projects/test-project/traces/4bf92f3577b34da6a3ce929d0e0e4736","logging.googleapis.com/trace_sampled":true`
+	want := `^{` + trace + `,"message":"child log","severity":"INFO"}\n` +
+		`{"httpRequest":{"requestMethod":"GET","requestUrl":"http://example.com/path\?q=%5B1%5D","status":404,"responseSize":"9","userAgent":"test-agent","remoteIp":"192.0.2.1","latency":"[0-9.e-]+s","protocol":"HTTP/1.1"},` +
+		trace + `,"message":"GET /path","severity":"WARNING"}\n$`
+
+	match, err := regexp.Match(want, buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !match {
+		t.Errorf("mismatch output\ngot:  %swant: %s", buf.String(), want)
+	}
+}
+
+func TestMiddleware_hijack(t *testing.T) {
+	out := log.Writer()
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(out)
+
+	server := httptest.NewServer(gcpopt.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := w.(interface{ Unwrap() http.ResponseWriter }); !ok {
+			t.Error("ResponseWriter doesn't implement Unwrap")
+		}
+		conn, brw, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Errorf("hijack: %v", err)
+			return
+		}
+		defer conn.Close()
+		brw.WriteString("HTTP/1.1 200 OK\r\nContent-Length: 8\r\nConnection: close\r\n\r\nhijacked")
+		brw.Flush()
+	})))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != "hijacked" {
+		t.Errorf("mismatch body: %s", body)
+	}
+}
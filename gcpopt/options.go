@@ -2,6 +2,7 @@ package gcpopt
 
 import (
 	"log"
+	"strconv"
 	"strings"
 
 	"github.com/komem3/logplug"
@@ -26,44 +27,44 @@ var DefaultLevelConfig = logplug.LevelConfig{
 	Field: levelField,
 }
 
-// ErrorReportHook converts the specified levels to the format of an error report.
-//	https://cloud.google.com/error-reporting/docs/formatting-error-messages#@type
-func ErrorReportHook(levels ...logplug.Level) logplug.Hook {
-	alartLevel := make(map[logplug.Level]bool, len(levels))
-	for _, level := range levels {
-		alartLevel[level] = true
-	}
-	return func(enc logplug.Encoder) logplug.Encoder {
-		return logplug.EncoderFunc(func(p *logplug.Plug, m *logplug.MessageElement) error {
-			if _, ok := alartLevel[m.GetString(levelField)]; ok {
-				m.AddString("@type", "type.googleapis.com/google.devtools.clouderrorreporting.v1beta1.ReportedErrorEvent")
-			}
-			return enc.Encode(p, m)
-		})
-	}
+// SourceLocation is sourceLocation of LogEntry.
+//	https://cloud.google.com/logging/docs/reference/v2/rest/v2/LogEntry#LogEntrySourceLocation
+type SourceLocation struct {
+	File string `json:"file"`
+	// Line is int64 encoded as string.
+	Line     string `json:"line,omitempty"`
+	Function string `json:"function,omitempty"`
 }
 
 // LocationModifyHook convert the value of location in log to sourceLocation.
+// The function name is resolved from the call stack at write time
+// when the frame of the log call site matches the location.
+//
+// If the location is missing, sourceLocation is not added.
+// If the line of the location is not a number, the whole location is used as file.
 func LocationModifyHook() logplug.Hook {
 	return func(enc logplug.Encoder) logplug.Encoder {
 		return logplug.EncoderFunc(func(p *logplug.Plug, m *logplug.MessageElement) error {
 			location := m.GetString(p.LocationField())
-
-			index := strings.LastIndexByte(location, ':')
-			if index == -1 && index-1 == len(location) {
+			if location == "" {
 				return enc.Encode(p, m)
 			}
-			sourceLocation := struct {
-				File string `json:"file"`
-				Line string `json:"line"`
-			}{
-				File: location[:index],
-				Line: location[index+1:],
-			}
-
 			delete(m.Elements(), p.LocationField())
 
-			m.Set("logging.googleapis.com/sourceLocation", sourceLocation)
+			sourceLocation := SourceLocation{File: location}
+			index := strings.LastIndexByte(location, ':')
+			if index != -1 {
+				if line, err := strconv.ParseInt(location[index+1:], 10, 64); err == nil && line > 0 {
+					sourceLocation.File = location[:index]
+					sourceLocation.Line = strconv.FormatInt(line, 10)
+
+					if i := matchFrame(m.Frames(), sourceLocation.File, int(line)); i != -1 {
+						sourceLocation.Function = m.Frames()[i].Function
+					}
+				}
+			}
+
+			m.Set(SourceLocationField, sourceLocation)
 			return enc.Encode(p, m)
 		})
 	}
@@ -75,6 +76,9 @@ func LocationModifyHook() logplug.Hook {
 // This assumes that gcpopt.logFlags has been set for log.
 //	log.SetFlags(gcpopt.LogFlags)
 //
+// SpecialFieldsHook isn't included, so the prefixes like [trace:...] and [labels.env:prod] are written as they are.
+// Add it to convert the prefixes to special fields, which is required by Middleware and Exporter.
+//	opts := append(gcpopt.NewGCPOptions("INFO"), logplug.Hooks(gcpopt.SpecialFieldsHook("")))
 func NewGCPOptions(minLevel logplug.Level) []logplug.Option {
 	conf := DefaultLevelConfig
 	conf.Min = minLevel
@@ -0,0 +1,76 @@
+package gcpopt_test
+
+import (
+	"bytes"
+	"log"
+	"regexp"
+	"testing"
+
+	"github.com/komem3/logplug"
+	"github.com/komem3/logplug/gcpopt"
+)
+
+func locationHook(location string) logplug.Hook {
+	return func(enc logplug.Encoder) logplug.Encoder {
+		return logplug.EncoderFunc(func(p *logplug.Plug, m *logplug.MessageElement) error {
+			m.Set(p.LocationField(), location)
+			return enc.Encode(p, m)
+		})
+	}
+}
+
+func TestLocationModifyHook(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		flag int
+		hook logplug.Hook
+		want string
+	}{
+		{
+			name: "long file", flag: log.Llongfile,
+			want: `^{"logging.googleapis.com/sourceLocation":{"file":"[[:graph:]]+/gcpopt/options_test\.go","line":"[0-9]+","function":"github.com/komem3/logplug/gcpopt_test.TestLocationModifyHook.func1"},"message":"long file"}\n$`,
+		},
+		{
+			name: "short file", flag: log.Lshortfile,
+			want: `^{"logging.googleapis.com/sourceLocation":{"file":"options_test\.go","line":"[0-9]+","function":"github.com/komem3/logplug/gcpopt_test.TestLocationModifyHook.func1"},"message":"short file"}\n$`,
+		},
+		{
+			name: "missing location",
+			want: `^{"message":"missing location"}\n$`,
+		},
+		{
+			name: "without colon", hook: locationHook("main.go"),
+			want: `^{"logging.googleapis.com/sourceLocation":{"file":"main.go"},"message":"without colon"}\n$`,
+		},
+		{
+			name: "not number line", hook: locationHook(`C:\app\main.go:abc`),
+			want: `^{"logging.googleapis.com/sourceLocation":{"file":"C:\\\\app\\\\main.go:abc"},"message":"not number line"}\n$`,
+		},
+		{
+			name: "mismatch frame", hook: locationHook("other.go:1"),
+			want: `^{"logging.googleapis.com/sourceLocation":{"file":"other.go","line":"1"},"message":"mismatch frame"}\n$`,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			hooks := []logplug.Hook{gcpopt.LocationModifyHook()}
+			if tt.hook != nil {
+				hooks = append([]logplug.Hook{tt.hook}, hooks...)
+			}
+
+			var buf bytes.Buffer
+			log.New(logplug.NewJSONPlug(&buf, logplug.LogFlag(tt.flag), logplug.Hooks(hooks...)), "", tt.flag).
+				Print(tt.name)
+
+			match, err := regexp.Match(tt.want, buf.Bytes())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !match {
+				t.Errorf("mismatch output\ngot:  %swant: %s", buf.String(), tt.want)
+			}
+		})
+	}
+}
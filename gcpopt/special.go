@@ -0,0 +1,270 @@
+package gcpopt
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/komem3/logplug"
+)
+
+// Special field names of structured logging.
+//	https://cloud.google.com/logging/docs/structured-logging#special-payload-fields
+const (
+	HTTPRequestField    = "httpRequest"
+	LabelsField         = "logging.googleapis.com/labels"
+	OperationField      = "logging.googleapis.com/operation"
+	InsertIDField       = "logging.googleapis.com/insertId"
+	TraceField          = "logging.googleapis.com/trace"
+	SpanIDField         = "logging.googleapis.com/spanId"
+	TraceSampledField   = "logging.googleapis.com/trace_sampled"
+	SourceLocationField = "logging.googleapis.com/sourceLocation"
+)
+
+// Prefix keys converted to special fields by SpecialFieldsHook.
+//	log.Print("[httpRequest.status:200][labels.env:prod][operation.id:job-1][trace:0123abcd] message")
+const (
+	HTTPRequestPrefix = "httpRequest."
+	LabelsPrefix      = "labels."
+	OperationPrefix   = "operation."
+	InsertIDKey       = "insertId"
+	TraceKey          = "trace"
+	SpanIDKey         = "spanId"
+	TraceSampledKey   = "trace_sampled"
+	LabelsKey         = "labels"
+)
+
+// HTTPRequest is httpRequest of LogEntry.
+//	https://cloud.google.com/logging/docs/reference/v2/rest/v2/LogEntry#HttpRequest
+type HTTPRequest struct {
+	RequestMethod                  string `json:"requestMethod,omitempty"`
+	RequestURL                     string `json:"requestUrl,omitempty"`
+	RequestSize                    string `json:"requestSize,omitempty"`
+	Status                         int    `json:"status,omitempty"`
+	ResponseSize                   string `json:"responseSize,omitempty"`
+	UserAgent                      string `json:"userAgent,omitempty"`
+	RemoteIP                       string `json:"remoteIp,omitempty"`
+	ServerIP                       string `json:"serverIp,omitempty"`
+	Referer                        string `json:"referer,omitempty"`
+	Latency                        string `json:"latency,omitempty"`
+	CacheLookup                    bool   `json:"cacheLookup,omitempty"`
+	CacheHit                       bool   `json:"cacheHit,omitempty"`
+	CacheValidatedWithOriginServer bool   `json:"cacheValidatedWithOriginServer,omitempty"`
+	CacheFillBytes                 string `json:"cacheFillBytes,omitempty"`
+	Protocol                       string `json:"protocol,omitempty"`
+}
+
+// FormatLatency formats d as latency of HTTPRequest. e.g. "0.150s"
+func FormatLatency(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', -1, 64) + "s"
+}
+
+func (r *HTTPRequest) set(key string, v interface{}) {
+	s, _ := v.(string)
+	b, isBool := v.(bool)
+	if !isBool {
+		b, _ = strconv.ParseBool(s)
+	}
+
+	switch key {
+	case "requestMethod":
+		r.RequestMethod = s
+	case "requestUrl":
+		r.RequestURL = s
+	case "requestSize":
+		r.RequestSize = s
+	case "status":
+		r.Status, _ = strconv.Atoi(s)
+	case "responseSize":
+		r.ResponseSize = s
+	case "userAgent":
+		r.UserAgent = s
+	case "remoteIp":
+		r.RemoteIP = s
+	case "serverIp":
+		r.ServerIP = s
+	case "referer":
+		r.Referer = s
+	case "latency":
+		if d, err := time.ParseDuration(s); err == nil {
+			r.Latency = FormatLatency(d)
+		} else {
+			r.Latency = s
+		}
+	case "cacheLookup":
+		r.CacheLookup = b
+	case "cacheHit":
+		r.CacheHit = b
+	case "cacheValidatedWithOriginServer":
+		r.CacheValidatedWithOriginServer = b
+	case "cacheFillBytes":
+		r.CacheFillBytes = s
+	case "protocol":
+		r.Protocol = s
+	}
+}
+
+// Operation is operation of LogEntry.
+//	https://cloud.google.com/logging/docs/reference/v2/rest/v2/LogEntry#LogEntryOperation
+type Operation struct {
+	ID       string `json:"id,omitempty"`
+	Producer string `json:"producer,omitempty"`
+	First    bool   `json:"first,omitempty"`
+	Last     bool   `json:"last,omitempty"`
+}
+
+func (o *Operation) set(key string, v interface{}) {
+	s, _ := v.(string)
+	b, isBool := v.(bool)
+	if !isBool {
+		b, _ = strconv.ParseBool(s)
+	}
+
+	switch key {
+	case "id":
+		o.ID = s
+	case "producer":
+		o.Producer = s
+	case "first":
+		o.First = b
+	case "last":
+		o.Last = b
+	}
+}
+
+// TraceName returns trace name of LogEntry.
+//	projects/[PROJECT_ID]/traces/[TRACE_ID]
+// If traceID is already a trace name or projectID is empty, traceID is returned as it is.
+func TraceName(projectID, traceID string) string {
+	if projectID == "" || strings.HasPrefix(traceID, "projects/") {
+		return traceID
+	}
+	return "projects/" + projectID + "/traces/" + traceID
+}
+
+// SpecialFieldsHook converts prefix fields or structured values to special fields.
+// projectID is used to complete the trace id to the trace name.
+// If projectID is empty, ProjectID() is used.
+//
+// prefix fields:
+//	[httpRequest.<field>:<value>] -> httpRequest
+//	[labels.<key>:<value>]        -> logging.googleapis.com/labels
+//	[operation.<field>:<value>]   -> logging.googleapis.com/operation
+//	[insertId:<value>]            -> logging.googleapis.com/insertId
+//	[trace:<value>]               -> logging.googleapis.com/trace
+//	[spanId:<value>]              -> logging.googleapis.com/spanId
+//	[trace_sampled:<value>]       -> logging.googleapis.com/trace_sampled
+//
+// structured values:
+//	httpRequest: HTTPRequest or *HTTPRequest
+//	labels or logging.googleapis.com/labels: map[string]string
+//	logging.googleapis.com/operation: Operation or *Operation
+func SpecialFieldsHook(projectID string) logplug.Hook {
+	return func(enc logplug.Encoder) logplug.Encoder {
+		return logplug.EncoderFunc(func(p *logplug.Plug, m *logplug.MessageElement) error {
+			var (
+				request   *HTTPRequest
+				labels    map[string]string
+				operation *Operation
+			)
+
+			elements := m.Elements()
+			for key, v := range elements {
+				switch {
+				case strings.HasPrefix(key, HTTPRequestPrefix):
+					if request == nil {
+						request = structuredHTTPRequest(elements[HTTPRequestField])
+					}
+					request.set(key[len(HTTPRequestPrefix):], v)
+				case strings.HasPrefix(key, LabelsPrefix):
+					if labels == nil {
+						labels = structuredLabels(elements)
+					}
+					labels[key[len(LabelsPrefix):]] = fmt.Sprint(v)
+				case strings.HasPrefix(key, OperationPrefix):
+					if operation == nil {
+						operation = structuredOperation(elements[OperationField])
+					}
+					operation.set(key[len(OperationPrefix):], v)
+				case key == LabelsKey:
+					if _, ok := v.(map[string]string); !ok {
+						continue
+					}
+					if labels == nil {
+						labels = structuredLabels(elements)
+					}
+				default:
+					continue
+				}
+				delete(elements, key)
+			}
+
+			if request != nil {
+				m.Set(HTTPRequestField, request)
+			}
+			if labels != nil {
+				m.Set(LabelsField, labels)
+			}
+			if operation != nil {
+				m.Set(OperationField, operation)
+			}
+			if v, ok := elements[InsertIDKey]; ok {
+				delete(elements, InsertIDKey)
+				m.Set(InsertIDField, v)
+			}
+			if traceID := m.GetString(TraceKey); traceID != "" {
+				delete(elements, TraceKey)
+				id := projectID
+				if id == "" {
+					id = ProjectID()
+				}
+				m.Set(TraceField, TraceName(id, traceID))
+			}
+			if v, ok := elements[SpanIDKey]; ok {
+				delete(elements, SpanIDKey)
+				m.Set(SpanIDField, v)
+			}
+			if v, ok := elements[TraceSampledKey]; ok {
+				delete(elements, TraceSampledKey)
+				m.Set(TraceSampledField, v)
+			}
+			return enc.Encode(p, m)
+		})
+	}
+}
+
+func structuredHTTPRequest(v interface{}) *HTTPRequest {
+	switch v := v.(type) {
+	case *HTTPRequest:
+		r := *v
+		return &r
+	case HTTPRequest:
+		return &v
+	}
+	return &HTTPRequest{}
+}
+
+func structuredLabels(elements map[string]interface{}) map[string]string {
+	labels := make(map[string]string)
+	for _, key := range []string{LabelsField, LabelsKey} {
+		if v, ok := elements[key].(map[string]string); ok {
+			for k, e := range v {
+				labels[k] = e
+			}
+			delete(elements, key)
+		}
+	}
+	return labels
+}
+
+func structuredOperation(v interface{}) *Operation {
+	switch v := v.(type) {
+	case *Operation:
+		o := *v
+		return &o
+	case Operation:
+		return &v
+	}
+	return &Operation{}
+}
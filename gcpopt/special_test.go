@@ -0,0 +1,84 @@
+package gcpopt_test
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"testing"
+
+	"github.com/komem3/logplug"
+	"github.com/komem3/logplug/gcpopt"
+)
+
+func structuredHook(key string, v interface{}) logplug.Hook {
+	return func(enc logplug.Encoder) logplug.Encoder {
+		return logplug.EncoderFunc(func(p *logplug.Plug, m *logplug.MessageElement) error {
+			m.Set(key, v)
+			return enc.Encode(p, m)
+		})
+	}
+}
+
+func TestSpecialFieldsHook(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		prefix string
+		hook   logplug.Hook
+		want   string
+	}{
+		{
+			name:   "http request",
+			prefix: "[httpRequest.requestMethod:GET][httpRequest.requestUrl:https://example.com/?q=1][httpRequest.status:404][httpRequest.latency:150ms][httpRequest.cacheHit:true]",
+			want:   `{"httpRequest":{"requestMethod":"GET","requestUrl":"https://example.com/?q=1","status":404,"latency":"0.15s","cacheHit":true},"message":"http request"}`,
+		},
+		{
+			name: "labels", prefix: "[labels.env:prod][labels.canary:true]",
+			want: `{"logging.googleapis.com/labels":{"canary":"true","env":"prod"},"message":"labels"}`,
+		},
+		{
+			name: "operation", prefix: "[operation.id:job-1][operation.producer:batch][operation.first:true]",
+			want: `{"logging.googleapis.com/operation":{"id":"job-1","producer":"batch","first":true},"message":"operation"}`,
+		},
+		{
+			name:   "trace",
+			prefix: "[trace:4bf92f3577b34da6a3ce929d0e0e4736][spanId:00f067aa0ba902b7][trace_sampled:true][insertId:42]",
+			want:   `{"logging.googleapis.com/insertId":"42","logging.googleapis.com/spanId":"00f067aa0ba902b7","logging.googleapis.com/trace":"projects/test-project/traces/4bf92f3577b34da6a3ce929d0e0e4736","logging.googleapis.com/trace_sampled":true,"message":"trace"}`,
+		},
+		{
+			name: "trace name", prefix: "[trace:projects/other/traces/abc]",
+			want: `{"logging.googleapis.com/trace":"projects/other/traces/abc","message":"trace name"}`,
+		},
+		{
+			name: "structured http request", prefix: "[httpRequest.status:500]",
+			hook: structuredHook(gcpopt.HTTPRequestField, gcpopt.HTTPRequest{RequestMethod: "POST", Status: 200}),
+			want: `{"httpRequest":{"requestMethod":"POST","status":500},"message":"structured http request"}`,
+		},
+		{
+			name: "structured labels", prefix: "[labels.env:prod]",
+			hook: structuredHook(gcpopt.LabelsKey, map[string]string{"app": "api"}),
+			want: `{"logging.googleapis.com/labels":{"app":"api","env":"prod"},"message":"structured labels"}`,
+		},
+		{
+			name: "not labels map", prefix: "[labels:plain]",
+			want: `{"labels":"plain","message":"not labels map"}`,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			hooks := []logplug.Hook{gcpopt.SpecialFieldsHook("test-project")}
+			if tt.hook != nil {
+				hooks = append([]logplug.Hook{tt.hook}, hooks...)
+			}
+
+			var buf bytes.Buffer
+			log.New(logplug.NewJSONPlug(&buf, logplug.Hooks(hooks...)), tt.prefix, 0).
+				Print(tt.name)
+
+			if strings.TrimRight(buf.String(), "\n") != tt.want {
+				t.Errorf("mismatch output\ngot:  %swant: %s", buf.String(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package gcpopt
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/komem3/logplug/internal/traceparent"
+)
+
+// CloudTraceHeader is the header of trace context used by Google Cloud.
+//	X-Cloud-Trace-Context: TRACE_ID/SPAN_ID;o=OPTIONS
+const CloudTraceHeader = "X-Cloud-Trace-Context"
+
+// ProjectIDEnvs are environment variables of project id in order of priority.
+var ProjectIDEnvs = []string{"GOOGLE_CLOUD_PROJECT", "GCP_PROJECT", "GCLOUD_PROJECT"}
+
+var projectID atomic.Value
+
+// SetProjectID overrides the project id used for trace name.
+func SetProjectID(id string) {
+	projectID.Store(id)
+}
+
+// ProjectID returns the project id set by SetProjectID or ProjectIDEnvs.
+func ProjectID() string {
+	if id, _ := projectID.Load().(string); id != "" {
+		return id
+	}
+	for _, env := range ProjectIDEnvs {
+		if id := os.Getenv(env); id != "" {
+			return id
+		}
+	}
+	return ""
+}
+
+// TraceContext is trace context of a request.
+type TraceContext struct {
+	TraceID string
+	SpanID  string
+	Sampled bool
+}
+
+// ParseCloudTraceHeader parses the value of X-Cloud-Trace-Context.
+// The decimal span id is converted to 16 hex digits.
+func ParseCloudTraceHeader(h string) (TraceContext, bool) {
+	var tc TraceContext
+
+	if index := strings.Index(h, ";o="); index != -1 {
+		tc.Sampled = h[index+3:] == "1"
+		h = h[:index]
+	}
+	if index := strings.IndexByte(h, '/'); index != -1 {
+		span, err := strconv.ParseUint(h[index+1:], 10, 64)
+		if err == nil && span != 0 {
+			tc.SpanID = leftPad(strconv.FormatUint(span, 16), 16)
+		}
+		h = h[:index]
+	}
+	tc.TraceID = strings.ToLower(h)
+	if !traceparent.ValidTraceID(tc.TraceID) {
+		return TraceContext{}, false
+	}
+	return tc, true
+}
+
+func leftPad(s string, n int) string {
+	if len(s) >= n {
+		return s
+	}
+	return strings.Repeat("0", n-len(s)) + s
+}
+
+// TraceContextFromRequest extracts trace context from W3C traceparent or X-Cloud-Trace-Context header.
+// traceparent has priority.
+func TraceContextFromRequest(r *http.Request) (TraceContext, bool) {
+	if tp, ok := traceparent.Parse(r.Header.Get(traceparent.Header)); ok {
+		return TraceContext{TraceID: tp.TraceID, SpanID: tp.SpanID, Sampled: tp.Sampled}, true
+	}
+	return ParseCloudTraceHeader(r.Header.Get(CloudTraceHeader))
+}
+
+type traceContextKey struct{}
+
+// ContextWithTrace returns a copy of ctx with tc.
+func ContextWithTrace(ctx context.Context, tc TraceContext) context.Context {
+	return context.WithValue(ctx, traceContextKey{}, tc)
+}
+
+// TraceFromContext returns trace context in ctx.
+func TraceFromContext(ctx context.Context) (TraceContext, bool) {
+	tc, ok := ctx.Value(traceContextKey{}).(TraceContext)
+	return tc, ok
+}
+
+// ContextFromRequest returns the context of r with the trace context in the headers of r.
+func ContextFromRequest(r *http.Request) context.Context {
+	if tc, ok := TraceContextFromRequest(r); ok {
+		return ContextWithTrace(r.Context(), tc)
+	}
+	return r.Context()
+}
+
+// TracePrefix returns prefix of the trace context in ctx.
+//	[logging.googleapis.com/trace:projects/PROJECT_ID/traces/TRACE_ID][logging.googleapis.com/spanId:SPAN_ID][logging.googleapis.com/trace_sampled:true]
+// If the project id is unknown, the trace is written as [trace:TRACE_ID] and completed by SpecialFieldsHook.
+func TracePrefix(ctx context.Context) string {
+	tc, ok := TraceFromContext(ctx)
+	if !ok {
+		return ""
+	}
+
+	var b strings.Builder
+	if id := escapePrefixValue(ProjectID()); id != "" {
+		b.WriteString("[" + TraceField + ":" + TraceName(id, tc.TraceID) + "]")
+	} else {
+		b.WriteString("[" + TraceKey + ":" + tc.TraceID + "]")
+	}
+	if tc.SpanID != "" {
+		b.WriteString("[" + SpanIDField + ":" + tc.SpanID + "]")
+	}
+	b.WriteString("[" + TraceSampledField + ":" + strconv.FormatBool(tc.Sampled) + "]")
+	return b.String()
+}
+
+// escapePrefixValue removes characters that break the prefix.
+func escapePrefixValue(s string) string {
+	return strings.NewReplacer("[", "", "]", "", "\n", "").Replace(s)
+}
+
+type loggerKey struct{}
+
+// ContextWithLogger returns a copy of ctx with l.
+func ContextWithLogger(ctx context.Context, l *log.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, l)
+}
+
+// LoggerFromContext returns a logger that has the trace prefix of ctx.
+// If ctx has a logger set by ContextWithLogger or Middleware, it is returned.
+// Otherwise the logger writes to the output of the standard logger.
+//	l := gcpopt.LoggerFromContext(gcpopt.ContextFromRequest(r))
+//	l.Print("request log")
+func LoggerFromContext(ctx context.Context) *log.Logger {
+	if l, ok := ctx.Value(loggerKey{}).(*log.Logger); ok {
+		return l
+	}
+	return log.New(log.Writer(), TracePrefix(ctx)+log.Prefix(), log.Flags())
+}
@@ -0,0 +1,97 @@
+package gcpopt_test
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/komem3/logplug"
+	"github.com/komem3/logplug/gcpopt"
+	"github.com/komem3/logplug/internal/testenv"
+)
+
+func TestTracePrefix(t *testing.T) {
+	for _, tt := range []struct {
+		name      string
+		header    map[string]string
+		projectID string
+		want      string
+	}{
+		{
+			name: "no header",
+			want: "",
+		},
+		{
+			name:      "traceparent",
+			header:    map[string]string{"traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+			projectID: "test-project",
+			want:      "[logging.googleapis.com/trace:projects/test-project/traces/4bf92f3577b34da6a3ce929d0e0e4736][logging.googleapis.com/spanId:00f067aa0ba902b7][logging.googleapis.com/trace_sampled:true]",
+		},
+		{
+			name:      "cloud trace context",
+			header:    map[string]string{"X-Cloud-Trace-Context": "105445aa7843bc8bf206b12000100000/1;o=0"},
+			projectID: "test-project",
+			want:      "[logging.googleapis.com/trace:projects/test-project/traces/105445aa7843bc8bf206b12000100000][logging.googleapis.com/spanId:0000000000000001][logging.googleapis.com/trace_sampled:false]",
+		},
+		{
+			name: "traceparent has priority",
+			header: map[string]string{
+				"traceparent":           "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00",
+				"X-Cloud-Trace-Context": "105445aa7843bc8bf206b12000100000/1;o=1",
+			},
+			projectID: "test-project",
+			want:      "[logging.googleapis.com/trace:projects/test-project/traces/4bf92f3577b34da6a3ce929d0e0e4736][logging.googleapis.com/spanId:00f067aa0ba902b7][logging.googleapis.com/trace_sampled:false]",
+		},
+		{
+			name:   "without project",
+			header: map[string]string{"X-Cloud-Trace-Context": "105445aa7843bc8bf206b12000100000;o=1"},
+			want:   "[trace:105445aa7843bc8bf206b12000100000][logging.googleapis.com/trace_sampled:true]",
+		},
+		{
+			name:   "invalid header",
+			header: map[string]string{"X-Cloud-Trace-Context": "invalid]/1;o=1"},
+			want:   "",
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			testenv.Setenv(t, "GOOGLE_CLOUD_PROJECT", tt.projectID)
+
+			r := httptest.NewRequest("GET", "/", nil)
+			for key, v := range tt.header {
+				r.Header.Set(key, v)
+			}
+
+			if got := gcpopt.TracePrefix(gcpopt.ContextFromRequest(r)); got != tt.want {
+				t.Errorf("mismatch prefix\ngot:  %s\nwant: %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoggerFromContext(t *testing.T) {
+	gcpopt.SetProjectID("override-project")
+	defer gcpopt.SetProjectID("")
+
+	var buf bytes.Buffer
+	out, flags, prefix := log.Writer(), log.Flags(), log.Prefix()
+	log.SetOutput(logplug.NewJSONPlug(&buf, logplug.Hooks(gcpopt.SpecialFieldsHook(""))))
+	log.SetFlags(0)
+	log.SetPrefix("[label:test]")
+	defer func() {
+		log.SetOutput(out)
+		log.SetFlags(flags)
+		log.SetPrefix(prefix)
+	}()
+
+	ctx := gcpopt.ContextWithTrace(context.Background(), gcpopt.TraceContext{TraceID: "4bf92f3577b34da6a3ce929d0e0e4736"})
+	gcpopt.LoggerFromContext(ctx).Print("context logger")
+
+	want := `{"label":"test","logging.googleapis.com/trace":"projects/override-project/traces/4bf92f3577b34da6a3ce929d0e0e4736","logging.googleapis.com/trace_sampled":false,"message":"context logger"}`
+	if strings.TrimRight(buf.String(), "\n") != want {
+		t.Errorf("mismatch output\ngot:  %swant: %s", buf.String(), want)
+	}
+}
@@ -0,0 +1,218 @@
+// Package batch buffers items of exporters and sends them in the background.
+package batch
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	defaultBatchSize     = 512
+	defaultFlushInterval = 5 * time.Second
+	defaultTimeout       = 30 * time.Second
+	defaultQueueBatches  = 16
+)
+
+// Config is option of Batcher.
+// Zero values are replaced with defaults.
+type Config struct {
+	// BatchSize is max number of items per send.
+	BatchSize int
+	// FlushInterval is interval of background flush.
+	// If negative, only the full batches are sent in the background.
+	FlushInterval time.Duration
+	// Timeout is timeout of a send.
+	Timeout time.Duration
+	// MaxQueueSize is max number of buffered items. Default is 16 times BatchSize.
+	// The items added to the full queue are dropped and the count is reported to ErrorHandler.
+	MaxQueueSize int
+	// ErrorHandler is called when the background send fails or the items are dropped.
+	ErrorHandler func(err error)
+}
+
+// DroppedError reports the number of items dropped by the full queue.
+type DroppedError struct {
+	Count int
+}
+
+func (e *DroppedError) Error() string {
+	return fmt.Sprintf("batch: %d items dropped by full queue", e.Count)
+}
+
+// SendFunc sends a batch.
+type SendFunc func(ctx context.Context, items []interface{}) error
+
+// Batcher buffers items and sends them in batches.
+// Add never sends items, so the logging isn't blocked by the slow endpoint.
+type Batcher struct {
+	conf Config
+	send SendFunc
+
+	mu      sync.Mutex
+	items   []interface{}
+	dropped int
+	closed  bool
+
+	sendMu sync.Mutex
+	full   chan struct{}
+	done   chan struct{}
+	wg     sync.WaitGroup
+	once   sync.Once
+}
+
+// New create a new Batcher and starts the background loop.
+func New(conf Config, send SendFunc) *Batcher {
+	if conf.BatchSize <= 0 {
+		conf.BatchSize = defaultBatchSize
+	}
+	if conf.FlushInterval == 0 {
+		conf.FlushInterval = defaultFlushInterval
+	}
+	if conf.Timeout <= 0 {
+		conf.Timeout = defaultTimeout
+	}
+	if conf.MaxQueueSize <= 0 {
+		conf.MaxQueueSize = conf.BatchSize * defaultQueueBatches
+	}
+	if conf.MaxQueueSize < conf.BatchSize {
+		conf.MaxQueueSize = conf.BatchSize
+	}
+	if conf.ErrorHandler == nil {
+		conf.ErrorHandler = func(error) {}
+	}
+
+	b := &Batcher{
+		conf:  conf,
+		send:  send,
+		items: make([]interface{}, 0, conf.BatchSize),
+		full:  make(chan struct{}, 1),
+		done:  make(chan struct{}),
+	}
+	b.wg.Add(1)
+	go b.loop()
+	return b
+}
+
+// Add buffers item and signals the background loop when a batch is full.
+// If the queue is full, item is dropped. After Close, Add does nothing.
+func (b *Batcher) Add(item interface{}) {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return
+	}
+	if len(b.items) >= b.conf.MaxQueueSize {
+		b.dropped++
+	} else {
+		b.items = append(b.items, item)
+	}
+	full := len(b.items) >= b.conf.BatchSize
+	b.mu.Unlock()
+
+	if full {
+		select {
+		case b.full <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Flush sends all buffered items and returns the first error.
+func (b *Batcher) Flush(ctx context.Context) error {
+	return b.flush(ctx, false)
+}
+
+// Close stops the background loop and sends buffered items.
+// The count of the dropped items is returned as DroppedError if no send fails.
+func (b *Batcher) Close() error {
+	b.mu.Lock()
+	b.closed = true
+	b.mu.Unlock()
+
+	b.once.Do(func() {
+		close(b.done)
+	})
+	b.wg.Wait()
+	err := b.Flush(context.Background())
+	if derr := b.takeDropped(); derr != nil && err == nil {
+		err = derr
+	}
+	return err
+}
+
+// takeDropped returns DroppedError of the items dropped since the last call.
+func (b *Batcher) takeDropped() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.dropped == 0 {
+		return nil
+	}
+	err := &DroppedError{Count: b.dropped}
+	b.dropped = 0
+	return err
+}
+
+// flush sends buffered items in batches. If fullOnly, the last partial batch is kept.
+func (b *Batcher) flush(ctx context.Context, fullOnly bool) error {
+	b.sendMu.Lock()
+	defer b.sendMu.Unlock()
+
+	var firstErr error
+	for {
+		b.mu.Lock()
+		n := len(b.items)
+		if n > b.conf.BatchSize {
+			n = b.conf.BatchSize
+		}
+		if n == 0 || (fullOnly && n < b.conf.BatchSize) {
+			b.mu.Unlock()
+			return firstErr
+		}
+		items := b.items[:n:n]
+		b.items = append(make([]interface{}, 0, b.conf.BatchSize), b.items[n:]...)
+		b.mu.Unlock()
+
+		if err := b.sendBatch(ctx, items); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+}
+
+func (b *Batcher) sendBatch(ctx context.Context, items []interface{}) error {
+	ctx, cancel := context.WithTimeout(ctx, b.conf.Timeout)
+	defer cancel()
+	return b.send(ctx, items)
+}
+
+func (b *Batcher) loop() {
+	defer b.wg.Done()
+
+	var tick <-chan time.Time
+	if b.conf.FlushInterval > 0 {
+		ticker := time.NewTicker(b.conf.FlushInterval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	for {
+		select {
+		case <-b.done:
+			return
+		case <-b.full:
+			b.report(b.flush(context.Background(), true))
+		case <-tick:
+			b.report(b.flush(context.Background(), false))
+		}
+	}
+}
+
+// report calls ErrorHandler with err and the count of the dropped items.
+func (b *Batcher) report(err error) {
+	if err != nil {
+		b.conf.ErrorHandler(err)
+	}
+	if err := b.takeDropped(); err != nil {
+		b.conf.ErrorHandler(err)
+	}
+}
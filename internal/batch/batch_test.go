@@ -0,0 +1,138 @@
+package batch_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/komem3/logplug/internal/batch"
+)
+
+func TestBatcher_fullOnly(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		batches [][]interface{}
+	)
+	sent := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		n := 0
+		for _, batch := range batches {
+			n += len(batch)
+		}
+		return n
+	}
+	b := batch.New(batch.Config{BatchSize: 2, FlushInterval: -1}, func(ctx context.Context, items []interface{}) error {
+		mu.Lock()
+		defer mu.Unlock()
+		batches = append(batches, items)
+		return nil
+	})
+	defer b.Close()
+
+	for i := 0; i < 5; i++ {
+		b.Add(i)
+	}
+	for i := 0; i < 100 && sent() < 4; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	// the background loop keeps the partial batch.
+	time.Sleep(10 * time.Millisecond)
+	if got := sent(); got != 4 {
+		t.Fatalf("mismatch sent items before flush\ngot:  %d\nwant: 4", got)
+	}
+	if err := b.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := [][]interface{}{{0, 1}, {2, 3}, {4}}
+	if !reflect.DeepEqual(batches, want) {
+		t.Errorf("mismatch batches\ngot:  %v\nwant: %v", batches, want)
+	}
+}
+
+func TestBatcher_Flush(t *testing.T) {
+	var batches [][]interface{}
+	b := batch.New(batch.Config{BatchSize: 10, FlushInterval: -1}, func(ctx context.Context, items []interface{}) error {
+		batches = append(batches, items)
+		if len(batches) == 1 {
+			return errors.New("unavailable")
+		}
+		return nil
+	})
+
+	b.Add(0)
+	b.Add(1)
+	if err := b.Flush(context.Background()); err == nil {
+		t.Error("expected error")
+	}
+	b.Add(2)
+	if err := b.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := [][]interface{}{{0, 1}, {2}}
+	if !reflect.DeepEqual(batches, want) {
+		t.Errorf("mismatch batches\ngot:  %v\nwant: %v", batches, want)
+	}
+}
+
+func TestBatcher_MaxQueueSize(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		sent    []interface{}
+		errs    []error
+		started = make(chan struct{}, 1)
+		unblock = make(chan struct{})
+	)
+	b := batch.New(batch.Config{
+		BatchSize:     2,
+		FlushInterval: -1,
+		MaxQueueSize:  4,
+		ErrorHandler: func(err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			errs = append(errs, err)
+		},
+	}, func(ctx context.Context, items []interface{}) error {
+		select {
+		case started <- struct{}{}:
+			<-unblock
+		default:
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		sent = append(sent, items...)
+		return nil
+	})
+
+	b.Add(0)
+	b.Add(1)
+	<-started
+	for i := 2; i < 8; i++ {
+		b.Add(i)
+	}
+	close(unblock)
+	if err := b.Close(); err != nil {
+		t.Fatal(err)
+	}
+	b.Add(8)
+	if err := b.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(sent) != 6 {
+		t.Errorf("mismatch sent items\ngot:  %v\nwant: [0 1 2 3 4 5]", sent)
+	}
+	var dropped *batch.DroppedError
+	if len(errs) != 1 || !errors.As(errs[0], &dropped) || dropped.Count != 2 {
+		t.Errorf("mismatch errors\ngot:  %v\nwant: [batch: 2 items dropped by full queue]", errs)
+	}
+}
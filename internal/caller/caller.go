@@ -0,0 +1,68 @@
+// Package caller finds the frames of the log call site.
+package caller
+
+import (
+	"runtime"
+	"strings"
+)
+
+const modulePath = "github.com/komem3/logplug"
+
+// Package returns the package path of function name.
+//	github.com/komem3/logplug.(*Plug).Write -> github.com/komem3/logplug
+func Package(function string) string {
+	slash := strings.LastIndexByte(function, '/')
+	dot := strings.IndexByte(function[slash+1:], '.')
+	if dot == -1 {
+		return function
+	}
+	return function[:slash+1+dot]
+}
+
+// Internal reports whether function belongs to the runtime, log or logplug packages.
+func Internal(function string) bool {
+	switch pkg := Package(function); {
+	case pkg == "runtime", pkg == "log", pkg == modulePath:
+		return true
+	case strings.HasPrefix(pkg, modulePath+"/"):
+		return !strings.HasSuffix(pkg, "_test")
+	}
+	return false
+}
+
+// Frames returns the frames of the call stack from the log call site.
+// pcs is the program counters captured by runtime.Callers in the writer of log.
+// The log call site is the first frame after the last frame of the log package,
+// so the writers between the log package and Plug like io.MultiWriter are skipped.
+// If the stack has no frame of the log package, the frames of Internal are skipped.
+// Then the frames of Internal like Logger of logplug are skipped.
+func Frames(pcs []uintptr) []runtime.Frame {
+	if len(pcs) == 0 {
+		return nil
+	}
+	frames := runtime.CallersFrames(pcs)
+
+	stack := make([]runtime.Frame, 0, len(pcs))
+	for {
+		frame, more := frames.Next()
+		stack = append(stack, frame)
+		if !more {
+			break
+		}
+	}
+	return callSite(stack)
+}
+
+// callSite returns the frames from the log call site in stack.
+func callSite(stack []runtime.Frame) []runtime.Frame {
+	start := 0
+	for i, frame := range stack {
+		if Package(frame.Function) == "log" {
+			start = i + 1
+		}
+	}
+	for start < len(stack) && Internal(stack[start].Function) {
+		start++
+	}
+	return stack[start:]
+}
@@ -0,0 +1,111 @@
+package caller
+
+import (
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+func TestCallSite(t *testing.T) {
+	stack := func(functions ...string) []runtime.Frame {
+		frames := make([]runtime.Frame, len(functions))
+		for i, function := range functions {
+			frames[i] = runtime.Frame{Function: function}
+		}
+		return frames
+	}
+
+	for _, tt := range []struct {
+		name  string
+		stack []runtime.Frame
+		want  []string
+	}{
+		{
+			name:  "direct",
+			stack: stack("github.com/komem3/logplug.(*Plug).Write", "log.(*Logger).output", "log.Printf", "main.main", "runtime.main"),
+			want:  []string{"main.main", "runtime.main"},
+		},
+		{
+			name: "writer between log and plug",
+			stack: stack("github.com/komem3/logplug.(*Plug).Write", "io.(*multiWriter).Write", "bufio.(*Writer).Flush",
+				"log.(*Logger).output", "log.(*Logger).Print", "main.main"),
+			want: []string{"main.main"},
+		},
+		{
+			name: "logplug logger",
+			stack: stack("github.com/komem3/logplug.(*fieldWriter).Write", "log.(*Logger).output", "log.(*Logger).Output",
+				"github.com/komem3/logplug.(*Logger).Print", "main.main"),
+			want: []string{"main.main"},
+		},
+		{
+			name: "nested log call",
+			stack: stack("github.com/komem3/logplug.(*Plug).Write", "log.(*Logger).output", "log.Print",
+				"main.tee.Write", "log.(*Logger).output", "log.Print", "main.main"),
+			want: []string{"main.main"},
+		},
+		{
+			name:  "wrapper",
+			stack: stack("log.(*Logger).Output", "main.wrap", "main.main"),
+			want:  []string{"main.wrap", "main.main"},
+		},
+		{
+			name:  "without log",
+			stack: stack("github.com/komem3/logplug.(*Plug).Write", "runtime.call", "main.main"),
+			want:  []string{"main.main"},
+		},
+		{
+			name:  "test package of logplug",
+			stack: stack("log.Print", "github.com/komem3/logplug/gcpopt_test.TestHook"),
+			want:  []string{"github.com/komem3/logplug/gcpopt_test.TestHook"},
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var got []string
+			for _, frame := range callSite(tt.stack) {
+				got = append(got, frame.Function)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("mismatch frames\ngot:  %v\nwant: %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPackage(t *testing.T) {
+	for _, tt := range []struct {
+		function string
+		want     string
+	}{
+		{function: "main.main", want: "main"},
+		{function: "github.com/komem3/logplug.(*Plug).Write", want: "github.com/komem3/logplug"},
+		{function: "github.com/komem3/logplug/gcpopt.Middleware.func1", want: "github.com/komem3/logplug/gcpopt"},
+		{function: "gopkg.in/yaml%2ev3.Marshal", want: "gopkg.in/yaml%2ev3"},
+		{function: "noPackage", want: "noPackage"},
+	} {
+		if got := Package(tt.function); got != tt.want {
+			t.Errorf("mismatch package of %s\ngot:  %s\nwant: %s", tt.function, got, tt.want)
+		}
+	}
+}
+
+func TestInternal(t *testing.T) {
+	for _, tt := range []struct {
+		function string
+		want     bool
+	}{
+		{function: "runtime.goexit", want: true},
+		{function: "log.(*Logger).Output", want: true},
+		{function: "github.com/komem3/logplug.(*Logger).Print", want: true},
+		{function: "github.com/komem3/logplug/gcpopt.LocationModifyHook.func1.1", want: true},
+		{function: "github.com/komem3/logplug/gcpopt_test.TestHook", want: false},
+		{function: "github.com/komem3/logplugin.Run", want: false},
+		{function: "main.main", want: false},
+	} {
+		if got := Internal(tt.function); got != tt.want {
+			t.Errorf("mismatch internal of %s\ngot:  %t\nwant: %t", tt.function, got, tt.want)
+		}
+	}
+}
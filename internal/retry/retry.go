@@ -0,0 +1,182 @@
+// Package retry implements retry with exponential backoff for exporters.
+package retry
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Policy is a retry policy.
+// Zero values are replaced with defaults and a negative MaxRetries disables retry.
+type Policy struct {
+	MaxRetries     int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// RetryableStatus are the http status codes retried by Post. Default is DefaultRetryableStatus.
+	RetryableStatus []int
+}
+
+// DefaultRetryableStatus are the http status codes worth retrying for the most endpoints.
+var DefaultRetryableStatus = []int{
+	http.StatusTooManyRequests,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+const (
+	defaultMaxRetries     = 5
+	defaultInitialBackoff = 100 * time.Millisecond
+	defaultMaxBackoff     = 10 * time.Second
+)
+
+// Do calls fn until it succeeds, returns a permanent error or the retries are exhausted.
+// fn reports whether the returned error is retryable.
+func (p Policy) Do(ctx context.Context, fn func() (retryable bool, err error)) error {
+	p = p.withDefault()
+
+	for attempt := 0; ; attempt++ {
+		retryable, err := fn()
+		if err == nil || !retryable {
+			return err
+		}
+		if attempt >= p.MaxRetries {
+			if attempt == 0 {
+				return err
+			}
+			return fmt.Errorf("give up after %d retries: %w", attempt, err)
+		}
+
+		timer := time.NewTimer(p.wait(attempt, err))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func (p Policy) withDefault() Policy {
+	if p.MaxRetries == 0 {
+		p.MaxRetries = defaultMaxRetries
+	} else if p.MaxRetries < 0 {
+		p.MaxRetries = 0
+	}
+	if p.InitialBackoff <= 0 {
+		p.InitialBackoff = defaultInitialBackoff
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = defaultMaxBackoff
+	}
+	return p
+}
+
+// wait returns the wait time before the retry of attempt.
+// The wait time is After of AfterError or the exponential backoff capped by MaxBackoff.
+func (p Policy) wait(attempt int, err error) time.Duration {
+	var after *AfterError
+	if errors.As(err, &after) && after.After > 0 {
+		return after.After
+	}
+	backoff := p.InitialBackoff
+	for i := 0; i < attempt && backoff < p.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > p.MaxBackoff {
+		backoff = p.MaxBackoff
+	}
+	return backoff
+}
+
+// AfterError is an error that asks the caller to wait for After before retrying.
+type AfterError struct {
+	After time.Duration
+	Err   error
+}
+
+func (e *AfterError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *AfterError) Unwrap() error {
+	return e.Err
+}
+
+// JSONHeader returns the header of headers and the content type of json.
+func JSONHeader(headers map[string]string) http.Header {
+	header := make(http.Header, len(headers)+1)
+	for key, v := range headers {
+		header.Set(key, v)
+	}
+	header.Set("Content-Type", "application/json")
+	return header
+}
+
+// retryableStatus reports whether the http status code is in RetryableStatus of p.
+func (p Policy) retryableStatus(code int) bool {
+	codes := p.RetryableStatus
+	if codes == nil {
+		codes = DefaultRetryableStatus
+	}
+	for _, c := range codes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+// Post sends body to url with POST and retries on network errors and retryable status codes.
+func (p Policy) Post(ctx context.Context, client *http.Client, url string, header http.Header, body []byte) error {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return p.Do(ctx, func() (bool, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return false, err
+		}
+		for key, values := range header {
+			req.Header[key] = values
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return ctx.Err() == nil, err
+		}
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+
+		if resp.StatusCode/100 == 2 {
+			return false, nil
+		}
+		err = fmt.Errorf("post %s: unexpected status %s", url, resp.Status)
+		if !p.retryableStatus(resp.StatusCode) {
+			return false, err
+		}
+		if after := retryAfter(resp.Header.Get("Retry-After")); after > 0 {
+			err = &AfterError{After: after, Err: err}
+		}
+		return true, err
+	})
+}
+
+func retryAfter(v string) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if sec, err := strconv.Atoi(v); err == nil && sec > 0 {
+		return time.Duration(sec) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil && time.Until(t) > 0 {
+		return time.Until(t)
+	}
+	return 0
+}
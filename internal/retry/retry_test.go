@@ -0,0 +1,129 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryAfter(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		value string
+		min   time.Duration
+		max   time.Duration
+	}{
+		{name: "empty"},
+		{name: "seconds", value: "3", min: 3 * time.Second, max: 3 * time.Second},
+		{name: "zero seconds", value: "0"},
+		{name: "negative seconds", value: "-1"},
+		{name: "invalid", value: "soon"},
+		{name: "http date", value: time.Now().Add(time.Hour).UTC().Format(http.TimeFormat), min: 58 * time.Minute, max: time.Hour},
+		{name: "past http date", value: time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat)},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := retryAfter(tt.value); got < tt.min || got > tt.max {
+				t.Errorf("mismatch retry after\ngot:  %s\nwant: %s - %s", got, tt.min, tt.max)
+			}
+		})
+	}
+}
+
+func TestPolicy_wait(t *testing.T) {
+	p := Policy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}.withDefault()
+	retryable := errors.New("retryable")
+
+	for _, tt := range []struct {
+		name    string
+		attempt int
+		err     error
+		want    time.Duration
+	}{
+		{name: "first", attempt: 0, err: retryable, want: 100 * time.Millisecond},
+		{name: "second", attempt: 1, err: retryable, want: 200 * time.Millisecond},
+		{name: "fourth", attempt: 3, err: retryable, want: 800 * time.Millisecond},
+		{name: "cap", attempt: 4, err: retryable, want: time.Second},
+		{name: "no overflow", attempt: 100, err: retryable, want: time.Second},
+		{name: "retry after", attempt: 0, err: &AfterError{After: 3 * time.Second, Err: retryable}, want: 3 * time.Second},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := p.wait(tt.attempt, tt.err); got != tt.want {
+				t.Errorf("mismatch wait\ngot:  %s\nwant: %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolicy_Do(t *testing.T) {
+	for _, tt := range []struct {
+		name       string
+		maxRetries int
+		wantCalls  int32
+	}{
+		{name: "default", maxRetries: 0, wantCalls: defaultMaxRetries + 1},
+		{name: "two retries", maxRetries: 2, wantCalls: 3},
+		{name: "negative disables retry", maxRetries: -1, wantCalls: 1},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var calls int32
+			p := Policy{MaxRetries: tt.maxRetries, InitialBackoff: time.Microsecond, MaxBackoff: time.Microsecond}
+			err := p.Do(context.Background(), func() (bool, error) {
+				atomic.AddInt32(&calls, 1)
+				return true, errors.New("unavailable")
+			})
+			if err == nil {
+				t.Error("expected error")
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("mismatch calls\ngot:  %d\nwant: %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestPolicy_Post(t *testing.T) {
+	for _, tt := range []struct {
+		name      string
+		retryable []int
+		wantErr   bool
+		wantCalls int32
+	}{
+		{name: "default status", wantErr: true, wantCalls: 1},
+		{name: "configured status", retryable: []int{http.StatusInternalServerError}, wantCalls: 2},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var calls int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if atomic.AddInt32(&calls, 1) == 1 {
+					w.WriteHeader(http.StatusInternalServerError)
+				}
+			}))
+			defer server.Close()
+
+			p := Policy{InitialBackoff: time.Microsecond, RetryableStatus: tt.retryable}
+			err := p.Post(context.Background(), server.Client(), server.URL, JSONHeader(nil), []byte("{}"))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("mismatch calls\ngot:  %d\nwant: %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
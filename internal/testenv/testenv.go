@@ -0,0 +1,23 @@
+// Package testenv provides helpers for tests.
+package testenv
+
+import (
+	"os"
+	"testing"
+)
+
+// Setenv sets the environment variable during the test like testing.T.Setenv of Go 1.17.
+func Setenv(t testing.TB, key, value string) {
+	t.Helper()
+	prev, ok := os.LookupEnv(key)
+	if err := os.Setenv(key, value); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if ok {
+			os.Setenv(key, prev)
+		} else {
+			os.Unsetenv(key)
+		}
+	})
+}
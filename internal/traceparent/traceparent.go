@@ -0,0 +1,77 @@
+// Package traceparent parses W3C trace context values.
+//
+//	https://www.w3.org/TR/trace-context/#traceparent-header
+package traceparent
+
+import "strings"
+
+// Header is the name of the W3C trace context header.
+const Header = "traceparent"
+
+// TraceParent is a parsed traceparent value.
+type TraceParent struct {
+	TraceID string
+	SpanID  string
+	Sampled bool
+}
+
+// Parse parses a traceparent value like "00-<trace-id>-<span-id>-<flags>".
+// ok is false if the value is malformed or the ids are all zero.
+// The fields after flags are allowed only for the future versions.
+func Parse(s string) (tp TraceParent, ok bool) {
+	parts := strings.Split(strings.TrimSpace(s), "-")
+	if len(parts) < 4 || !isHex(parts[0], 2) || parts[0] == "ff" || (parts[0] == "00" && len(parts) != 4) {
+		return TraceParent{}, false
+	}
+	if !ValidTraceID(parts[1]) || !ValidSpanID(parts[2]) || !isHex(parts[3], 2) {
+		return TraceParent{}, false
+	}
+	return TraceParent{
+		TraceID: strings.ToLower(parts[1]),
+		SpanID:  strings.ToLower(parts[2]),
+		Sampled: hexValue(parts[3][1])&1 == 1,
+	}, true
+}
+
+// String returns tp in traceparent format.
+func (tp TraceParent) String() string {
+	flags := "00"
+	if tp.Sampled {
+		flags = "01"
+	}
+	return "00-" + tp.TraceID + "-" + tp.SpanID + "-" + flags
+}
+
+// ValidTraceID reports whether s is a 32 hex digits trace id which is not all zero.
+func ValidTraceID(s string) bool {
+	return isHex(s, 32) && strings.Trim(s, "0") != ""
+}
+
+// ValidSpanID reports whether s is a 16 hex digits span id which is not all zero.
+func ValidSpanID(s string) bool {
+	return isHex(s, 16) && strings.Trim(s, "0") != ""
+}
+
+func isHex(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if hexValue(s[i]) == 0xff {
+			return false
+		}
+	}
+	return true
+}
+
+func hexValue(c byte) byte {
+	switch {
+	case '0' <= c && c <= '9':
+		return c - '0'
+	case 'a' <= c && c <= 'f':
+		return c - 'a' + 10
+	case 'A' <= c && c <= 'F':
+		return c - 'A' + 10
+	}
+	return 0xff
+}
@@ -0,0 +1,85 @@
+package traceparent_test
+
+import (
+	"testing"
+
+	"github.com/komem3/logplug/internal/traceparent"
+)
+
+func TestParse(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		value  string
+		want   traceparent.TraceParent
+		wantOK bool
+	}{
+		{
+			name:   "sampled",
+			value:  "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			want:   traceparent.TraceParent{TraceID: "4bf92f3577b34da6a3ce929d0e0e4736", SpanID: "00f067aa0ba902b7", Sampled: true},
+			wantOK: true,
+		},
+		{
+			name:   "upper case and not sampled",
+			value:  " 00-4BF92F3577B34DA6A3CE929D0E0E4736-00F067AA0BA902B7-00 ",
+			want:   traceparent.TraceParent{TraceID: "4bf92f3577b34da6a3ce929d0e0e4736", SpanID: "00f067aa0ba902b7"},
+			wantOK: true,
+		},
+		{
+			name:   "future version with extra field",
+			value:  "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-03-extra",
+			want:   traceparent.TraceParent{TraceID: "4bf92f3577b34da6a3ce929d0e0e4736", SpanID: "00f067aa0ba902b7", Sampled: true},
+			wantOK: true,
+		},
+		{
+			name:  "version 00 with extra field",
+			value: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		},
+		{
+			name:  "version ff",
+			value: "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		},
+		{
+			name:  "invalid version",
+			value: "zz-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		},
+		{
+			name:  "zero trace id",
+			value: "00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		},
+		{
+			name:  "zero span id",
+			value: "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		},
+		{
+			name:  "short trace id",
+			value: "00-4bf92f3577b34da6a3ce929d0e0e47-00f067aa0ba902b7-01",
+		},
+		{
+			name:  "invalid flags",
+			value: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-0x",
+		},
+		{
+			name:  "missing flags",
+			value: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, ok := traceparent.Parse(tt.value)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("mismatch result\ngot:  %+v, %t\nwant: %+v, %t", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestTraceParent_String(t *testing.T) {
+	tp := traceparent.TraceParent{TraceID: "4bf92f3577b34da6a3ce929d0e0e4736", SpanID: "00f067aa0ba902b7", Sampled: true}
+	want := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	if got := tp.String(); got != want {
+		t.Errorf("mismatch output\ngot:  %s\nwant: %s", got, want)
+	}
+}
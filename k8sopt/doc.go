@@ -0,0 +1,36 @@
+/*
+Package k8sopt implements a hook that enriches logs with Kubernetes metadata.
+The metadata is read from the Downward API (environment variables and files) at Plug construction.
+	https://kubernetes.io/docs/concepts/workloads/pods/downward-api/
+
+Expected pod spec:
+	env:
+	- name: POD_NAME
+	  valueFrom: {fieldRef: {fieldPath: metadata.name}}
+	- name: POD_NAMESPACE
+	  valueFrom: {fieldRef: {fieldPath: metadata.namespace}}
+	- name: NODE_NAME
+	  valueFrom: {fieldRef: {fieldPath: spec.nodeName}}
+	- name: CONTAINER_NAME
+	  value: app
+	volumes:
+	- name: podinfo
+	  downwardAPI:
+	    items:
+	    - path: labels
+	      fieldRef: {fieldPath: metadata.labels}
+
+Usage:
+	logplug.NewJSONPlug(os.Stdout, logplug.Hooks(
+		k8sopt.Hook(k8sopt.Config{Labels: []string{"app"}}),
+	))
+	// output: {"k8s":{"container":{"name":"app"},"node":{"name":"node-1"},"pod":{"labels":{"app":"api"},"name":"api-7d9f","namespace":"default"}},"message":"..."}
+
+The metadata can be mapped into gcpopt labels.
+	logplug.NewJSONPlug(os.Stdout, append(gcpopt.NewGCPOptions("INFO"), logplug.Hooks(
+		k8sopt.Hook(k8sopt.Config{Prefix: gcpopt.LabelsPrefix}),
+		gcpopt.SpecialFieldsHook(""),
+	))...)
+	// output: {"logging.googleapis.com/labels":{"k8s.container.name":"app","k8s.node.name":"node-1","k8s.pod.name":"api-7d9f","k8s.pod.namespace":"default"},...}
+*/
+package k8sopt
@@ -0,0 +1,202 @@
+package k8sopt
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/komem3/logplug"
+)
+
+// DefaultDir is default directory of the Downward API volume.
+const DefaultDir = "/etc/podinfo"
+
+// DefaultEnv maps metadata to environment variables.
+var DefaultEnv = Env{
+	PodName:       "POD_NAME",
+	PodNamespace:  "POD_NAMESPACE",
+	NodeName:      "NODE_NAME",
+	ContainerName: "CONTAINER_NAME",
+}
+
+// Env is names of environment variables of metadata.
+type Env struct {
+	PodName       string
+	PodNamespace  string
+	NodeName      string
+	ContainerName string
+}
+
+// Config is option of Hook.
+type Config struct {
+	// Dir is directory of the Downward API volume. Default is DefaultDir.
+	// The files "name", "namespace", "nodename" and "labels" are read if they exist.
+	// The environment variables have priority over the files.
+	Dir string
+	// Env is names of environment variables. Default is DefaultEnv.
+	Env *Env
+	// Labels are pod label keys added to logs.
+	// If empty, no labels are added.
+	Labels []string
+
+	// Field is field name of nested metadata. Default is "k8s".
+	Field string
+	// Prefix makes metadata flat string fields like Prefix+"k8s.pod.name" instead of nesting.
+	// Set gcpopt.LabelsPrefix to map metadata into gcpopt labels.
+	Prefix string
+}
+
+// Metadata is Kubernetes metadata of the pod.
+type Metadata struct {
+	PodName       string
+	PodNamespace  string
+	NodeName      string
+	ContainerName string
+	Labels        map[string]string
+}
+
+// Read reads metadata from the environment variables and the Downward API volume.
+func Read(conf Config) Metadata {
+	if conf.Dir == "" {
+		conf.Dir = DefaultDir
+	}
+	env := DefaultEnv
+	if conf.Env != nil {
+		env = *conf.Env
+	}
+
+	md := Metadata{
+		PodName:       lookup(env.PodName, conf.Dir, "name"),
+		PodNamespace:  lookup(env.PodNamespace, conf.Dir, "namespace"),
+		NodeName:      lookup(env.NodeName, conf.Dir, "nodename"),
+		ContainerName: lookup(env.ContainerName, conf.Dir, "containername"),
+	}
+
+	if len(conf.Labels) > 0 {
+		all := readLabels(filepath.Join(conf.Dir, "labels"))
+		for _, key := range conf.Labels {
+			if v, ok := all[key]; ok {
+				if md.Labels == nil {
+					md.Labels = make(map[string]string, len(conf.Labels))
+				}
+				md.Labels[key] = v
+			}
+		}
+	}
+	return md
+}
+
+func lookup(env, dir, file string) string {
+	if env != "" {
+		if v := os.Getenv(env); v != "" {
+			return v
+		}
+	}
+	b, err := os.ReadFile(filepath.Join(dir, file))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(b))
+}
+
+// readLabels reads a file of the Downward API labels.
+//	app="api"
+//	tier="backend"
+func readLabels(path string) map[string]string {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	labels := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		index := strings.IndexByte(line, '=')
+		if index == -1 {
+			continue
+		}
+		v, err := strconv.Unquote(line[index+1:])
+		if err != nil {
+			v = line[index+1:]
+		}
+		labels[line[:index]] = v
+	}
+	return labels
+}
+
+// fields returns flat fields of md.
+func (md Metadata) fields() map[string]string {
+	fields := make(map[string]string, 4+len(md.Labels))
+	for key, v := range map[string]string{
+		"k8s.pod.name":       md.PodName,
+		"k8s.pod.namespace":  md.PodNamespace,
+		"k8s.node.name":      md.NodeName,
+		"k8s.container.name": md.ContainerName,
+	} {
+		if v != "" {
+			fields[key] = v
+		}
+	}
+	for key, v := range md.Labels {
+		fields["k8s.pod.labels."+key] = v
+	}
+	return fields
+}
+
+// nested returns nested object of md.
+func (md Metadata) nested() map[string]interface{} {
+	obj := make(map[string]interface{}, 3)
+	set := func(kind, key string, v interface{}) {
+		if s, ok := v.(string); ok && s == "" {
+			return
+		}
+		child, ok := obj[kind].(map[string]interface{})
+		if !ok {
+			child = make(map[string]interface{}, 3)
+			obj[kind] = child
+		}
+		child[key] = v
+	}
+	set("pod", "name", md.PodName)
+	set("pod", "namespace", md.PodNamespace)
+	if len(md.Labels) > 0 {
+		set("pod", "labels", md.Labels)
+	}
+	set("node", "name", md.NodeName)
+	set("container", "name", md.ContainerName)
+	return obj
+}
+
+// Hook adds Kubernetes metadata to logs.
+// The metadata is read once when the Plug is constructed.
+func Hook(conf Config) logplug.Hook {
+	if conf.Field == "" {
+		conf.Field = "k8s"
+	}
+	return func(enc logplug.Encoder) logplug.Encoder {
+		md := Read(conf)
+
+		if conf.Prefix != "" {
+			fields := md.fields()
+			return logplug.EncoderFunc(func(p *logplug.Plug, m *logplug.MessageElement) error {
+				for key, v := range fields {
+					m.Set(conf.Prefix+key, v)
+				}
+				return enc.Encode(p, m)
+			})
+		}
+
+		nested := md.nested()
+		if len(nested) == 0 {
+			return enc
+		}
+		return logplug.EncoderFunc(func(p *logplug.Plug, m *logplug.MessageElement) error {
+			m.Set(conf.Field, nested)
+			return enc.Encode(p, m)
+		})
+	}
+}
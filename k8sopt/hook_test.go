@@ -0,0 +1,64 @@
+package k8sopt_test
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/komem3/logplug"
+	"github.com/komem3/logplug/gcpopt"
+	"github.com/komem3/logplug/internal/testenv"
+	"github.com/komem3/logplug/k8sopt"
+)
+
+func TestHook(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "labels"), []byte("app=\"api\"\ntier=\"backend\"\nversion=\"v1\"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "namespace"), []byte("file-namespace\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	testenv.Setenv(t, "POD_NAME", "api-7d9f")
+	testenv.Setenv(t, "POD_NAMESPACE", "")
+	testenv.Setenv(t, "NODE_NAME", "node-1")
+	testenv.Setenv(t, "CONTAINER_NAME", "app")
+
+	for _, tt := range []struct {
+		name  string
+		hooks []logplug.Hook
+		want  string
+	}{
+		{
+			name:  "nested",
+			hooks: []logplug.Hook{k8sopt.Hook(k8sopt.Config{Dir: dir, Labels: []string{"app", "tier", "missing"}})},
+			want:  `{"k8s":{"container":{"name":"app"},"node":{"name":"node-1"},"pod":{"labels":{"app":"api","tier":"backend"},"name":"api-7d9f","namespace":"file-namespace"}},"message":"nested"}`,
+		},
+		{
+			name:  "custom field",
+			hooks: []logplug.Hook{k8sopt.Hook(k8sopt.Config{Dir: dir, Field: "kubernetes", Env: &k8sopt.Env{PodName: "POD_NAME"}})},
+			want:  `{"kubernetes":{"pod":{"name":"api-7d9f","namespace":"file-namespace"}},"message":"custom field"}`,
+		},
+		{
+			name: "gcp labels",
+			hooks: []logplug.Hook{
+				k8sopt.Hook(k8sopt.Config{Dir: dir, Labels: []string{"app"}, Prefix: gcpopt.LabelsPrefix}),
+				gcpopt.SpecialFieldsHook(""),
+			},
+			want: `{"logging.googleapis.com/labels":{"k8s.container.name":"app","k8s.node.name":"node-1","k8s.pod.labels.app":"api","k8s.pod.name":"api-7d9f","k8s.pod.namespace":"file-namespace"},"message":"gcp labels"}`,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			log.New(logplug.NewJSONPlug(&buf, logplug.Hooks(tt.hooks...)), "", 0).Print(tt.name)
+
+			if strings.TrimRight(buf.String(), "\n") != tt.want {
+				t.Errorf("mismatch output\ngot:  %swant: %s", buf.String(), tt.want)
+			}
+		})
+	}
+}
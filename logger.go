@@ -0,0 +1,129 @@
+package logplug
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+)
+
+// badKey is key of the value that has no key.
+const badKey = "!BADKEY"
+
+type field struct {
+	key   string
+	value interface{}
+}
+
+// fields converts key-value pairs to fields.
+func fields(kv []interface{}) []field {
+	fs := make([]field, 0, (len(kv)+1)/2)
+	for i := 0; i < len(kv); i += 2 {
+		if i+1 == len(kv) {
+			fs = append(fs, field{key: badKey, value: kv[i]})
+			break
+		}
+		key, ok := kv[i].(string)
+		if !ok {
+			key = fmt.Sprint(kv[i])
+		}
+		fs = append(fs, field{key: key, value: kv[i+1]})
+	}
+	return fs
+}
+
+var prefixValueReplacer = strings.NewReplacer("[", "(", "]", ")", "\n", " ")
+
+// fieldsPrefix formats fields as the prefix parsed by Plug.
+func fieldsPrefix(fs []field) string {
+	var b strings.Builder
+	for _, f := range fs {
+		b.WriteString("[" + prefixValueReplacer.Replace(f.key) + ":" + prefixValueReplacer.Replace(fmt.Sprint(f.value)) + "]")
+	}
+	return b.String()
+}
+
+// fieldWriter writes to Plug with the context and the typed fields.
+type fieldWriter struct {
+	plug   *Plug
+	ctx    context.Context
+	fields []field
+}
+
+// Write implements io.Writer.
+func (w *fieldWriter) Write(msgb []byte) (n int, err error) {
+	return w.plug.write(w.ctx, msgb, w.fields)
+}
+
+// newFieldLogger returns a logger writes to out with ctx and fields.
+// If out is Plug, ctx and the fields are handed directly to the Plug.
+// Otherwise the fields are written as the prefix.
+func newFieldLogger(ctx context.Context, out io.Writer, prefix string, flag int, fs []field) *log.Logger {
+	if plug, ok := out.(*Plug); ok && (ctx != nil || len(fs) > 0) {
+		return log.New(&fieldWriter{plug: plug, ctx: ctx, fields: fs}, prefix, flag)
+	}
+	if len(fs) == 0 {
+		return log.New(out, prefix, flag)
+	}
+	return log.New(out, fieldsPrefix(fs)+prefix, flag)
+}
+
+// Logger is a wrapper of log.Logger that has structured fields.
+// The fields are handed directly to the Plug without the text parsing, so the types of values are kept.
+// When the output isn't Plug, the fields are written as the prefix like "[key:value]".
+//	l := logplug.NewLogger(log.Default()).With("user_id", 100, "admin", true)
+//	l.Printf("[INFO]login")
+//	// {"admin":true,"level":"INFO","message":"login","user_id":100}
+type Logger struct {
+	out    io.Writer
+	prefix string
+	flag   int
+	fields []field
+	logger *log.Logger
+}
+
+// NewLogger create a new Logger that writes to the output of l with the prefix and the flags of l.
+func NewLogger(l *log.Logger) *Logger {
+	return &Logger{
+		out:    l.Writer(),
+		prefix: l.Prefix(),
+		flag:   l.Flags(),
+		logger: l,
+	}
+}
+
+// With returns a copy of l with the key-value pairs.
+// The key should be string. If the last key has no value, the key is "!BADKEY".
+func (l *Logger) With(kv ...interface{}) *Logger {
+	fs := make([]field, 0, len(l.fields)+(len(kv)+1)/2)
+	fs = append(fs, l.fields...)
+	fs = append(fs, fields(kv)...)
+	return &Logger{
+		out:    l.out,
+		prefix: l.prefix,
+		flag:   l.flag,
+		fields: fs,
+		logger: newFieldLogger(nil, l.out, l.prefix, l.flag, fs),
+	}
+}
+
+// Logger returns the log.Logger with the fields of l.
+func (l *Logger) Logger() *log.Logger {
+	return l.logger
+}
+
+// Print calls Output to print to the logger like log.Print.
+func (l *Logger) Print(v ...interface{}) {
+	_ = l.logger.Output(2, fmt.Sprint(v...))
+}
+
+// Printf calls Output to print to the logger like log.Printf.
+func (l *Logger) Printf(format string, v ...interface{}) {
+	_ = l.logger.Output(2, fmt.Sprintf(format, v...))
+}
+
+// Println calls Output to print to the logger like log.Println.
+func (l *Logger) Println(v ...interface{}) {
+	_ = l.logger.Output(2, fmt.Sprintln(v...))
+}
@@ -0,0 +1,90 @@
+package logplug_test
+
+import (
+	"bytes"
+	"log"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/komem3/logplug"
+)
+
+func TestLogger(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		with  [][]interface{}
+		print func(l *logplug.Logger)
+		want  string
+	}{
+		{
+			name:  "no fields",
+			print: func(l *logplug.Logger) { l.Print("[INFO]", "message") },
+			want:  `{"level":"INFO","message":"message"}`,
+		},
+		{
+			name:  "typed fields",
+			with:  [][]interface{}{{"user_id", 100, "admin", true, "ratio", 0.5}},
+			print: func(l *logplug.Logger) { l.Printf("[INFO]login %d", 1) },
+			want:  `{"admin":true,"level":"INFO","message":"login 1","ratio":0.5,"user_id":100}`,
+		},
+		{
+			name:  "chain",
+			with:  [][]interface{}{{"a", 1}, {"b", []int{1, 2}}},
+			print: func(l *logplug.Logger) { l.Println("[WARN]chain") },
+			want:  `{"a":1,"b":[1,2],"level":"WARN","message":"chain"}`,
+		},
+		{
+			name:  "prefix has priority",
+			with:  [][]interface{}{{"trace", 1}},
+			print: func(l *logplug.Logger) { l.Print("[trace:abc][INFO]message") },
+			want:  `{"level":"INFO","message":"message","trace":"abc"}`,
+		},
+		{
+			name:  "bad key",
+			with:  [][]interface{}{{"a", 1, "b"}},
+			print: func(l *logplug.Logger) { l.Print("[INFO]message") },
+			want:  `{"!BADKEY":"b","a":1,"level":"INFO","message":"message"}`,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+			l := logplug.NewLogger(log.New(logplug.NewJSONPlug(&buf, logplug.Hooks(
+				logplug.LevelHook(logplug.LevelConfig{Levels: []logplug.Level{"INFO", "WARN"}}),
+			)), "", 0))
+			for _, kv := range tt.with {
+				l = l.With(kv...)
+			}
+			tt.print(l)
+
+			if strings.TrimRight(buf.String(), "\n") != tt.want {
+				t.Errorf("mismatch output\ngot:  %swant: %s", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestLogger_plainWriter(t *testing.T) {
+	var buf bytes.Buffer
+	l := logplug.NewLogger(log.New(&buf, "[app:api]", 0)).With("user_id", 100, "name", "[x]")
+	l.Printf("[INFO]login")
+
+	want := "[user_id:100][name:(x)][app:api][INFO]login\n"
+	if buf.String() != want {
+		t.Errorf("mismatch output\ngot:  %swant: %s", buf.String(), want)
+	}
+}
+
+func TestLogger_location(t *testing.T) {
+	var buf bytes.Buffer
+	l := logplug.NewLogger(log.New(logplug.NewJSONPlug(&buf, logplug.LogFlag(log.Lshortfile)), "", log.Lshortfile))
+	l.With("a", 1).Print("message")
+
+	want := `{"a":1,"location":"logger_test\.go:[0-9]+","message":"message"}`
+	if !regexp.MustCompile("^" + want + "$").MatchString(strings.TrimRight(buf.String(), "\n")) {
+		t.Errorf("mismatch output\ngot:  %swant: %s", buf.String(), want)
+	}
+}
@@ -1,5 +1,9 @@
 package logplug
 
+import (
+	"time"
+)
+
 // Option is option of Plug.
 type Option func(p *Plug)
 
@@ -41,3 +45,40 @@ func LocationField(field string) Option {
 		p.locationField = field
 	}
 }
+
+// TimestampFormat set format of timestamp.
+// The timestamp is formatted after all hooks, so the hooks can get the timestamp as time.Time.
+//	logplug.NewJSONPlug(os.Stderr, logplug.TimestampFormat(logplug.UnixMilliFormat))
+func TimestampFormat(f TimeFormatter) Option {
+	return func(p *Plug) {
+		p.timeFormatter = f
+	}
+}
+
+// TimestampMode is mode of the timestamp of records.
+type TimestampMode int
+
+const (
+	// ParsedTimestamp uses only the timestamp parsed by LogFlag. This is default.
+	ParsedTimestamp TimestampMode = iota
+	// WriteTimestamp uses the time when Write is called.
+	WriteTimestamp
+	// PreferParsedTimestamp uses the parsed timestamp if present, otherwise the time when Write is called.
+	PreferParsedTimestamp
+)
+
+// TimestampSource set mode of the timestamp.
+// This is useful when LogFlag lacks log.Ldate and the records have no timestamp.
+//	logplug.NewJSONPlug(os.Stderr, logplug.LogFlag(log.Llongfile), logplug.TimestampSource(logplug.WriteTimestamp))
+func TimestampSource(mode TimestampMode) Option {
+	return func(p *Plug) {
+		p.timestampMode = mode
+	}
+}
+
+// Clock set function that returns current time used by WriteTimestamp. Default is time.Now.
+func Clock(now func() time.Time) Option {
+	return func(p *Plug) {
+		p.clock = now
+	}
+}
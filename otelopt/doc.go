@@ -0,0 +1,24 @@
+/*
+Package otelopt implements encoders for the OpenTelemetry log data model.
+This encoder is created by referring to the following.
+	https://opentelemetry.io/docs/specs/otel/logs/data-model/
+	https://opentelemetry.io/docs/specs/otlp/#otlphttp
+
+The message is converted to LogRecord as follows.
+	Body                 <- message field
+	SeverityText         <- level field
+	SeverityNumber       <- level field mapped by SeverityMap
+	Timestamp            <- timestamp field
+	TraceId, SpanId      <- trace_id, span_id or traceparent field
+	Attributes           <- other fields
+
+NewEncoder writes OTLP JSON to io.Writer and Exporter posts batches to an OTLP/HTTP endpoint.
+	exporter := otelopt.NewExporter(otelopt.ExporterConfig{
+		Endpoint: "http://localhost:4318/v1/logs",
+	})
+	defer exporter.Close()
+	log.SetOutput(logplug.NewPlug(exporter, logplug.Hooks(
+		logplug.LevelHook(logplug.LevelConfig{Levels: []logplug.Level{"DEBUG", "INFO", "WARN", "ERROR"}}),
+	)))
+*/
+package otelopt
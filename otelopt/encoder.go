@@ -0,0 +1,36 @@
+package otelopt
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/komem3/logplug"
+)
+
+// jsonEncoder writes a ExportRequest per message.
+type jsonEncoder struct {
+	encoder  *json.Encoder
+	resource map[string]interface{}
+	conf     RecordConfig
+}
+
+// Encode implements logplug.Encoder.
+func (e *jsonEncoder) Encode(p *logplug.Plug, m *logplug.MessageElement) error {
+	return e.encoder.Encode(NewExportRequest(e.resource, []LogRecord{NewLogRecord(p, m, e.conf)}))
+}
+
+// NewEncoder create a encoder that writes OTLP JSON lines to w.
+// Each line is a ExportLogsServiceRequest which contains one LogRecord.
+// resource is used as attributes of resource. e.g. {"service.name": "app"}
+func NewEncoder(w io.Writer, resource map[string]interface{}, conf RecordConfig) logplug.Encoder {
+	return &jsonEncoder{
+		encoder:  json.NewEncoder(w),
+		resource: resource,
+		conf:     conf.withDefault(),
+	}
+}
+
+// NewPlug create a plug that converts log to OTLP JSON.
+func NewPlug(w io.Writer, resource map[string]interface{}, conf RecordConfig, opts ...logplug.Option) *logplug.Plug {
+	return logplug.NewPlug(NewEncoder(w, resource, conf), opts...)
+}
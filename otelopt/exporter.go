@@ -0,0 +1,128 @@
+package otelopt
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/komem3/logplug"
+	"github.com/komem3/logplug/internal/batch"
+	"github.com/komem3/logplug/internal/retry"
+)
+
+// ExporterConfig is option of Exporter.
+type ExporterConfig struct {
+	// Endpoint is url of OTLP/HTTP logs. Default is "http://localhost:4318/v1/logs".
+	Endpoint string
+	// Headers are added to each request. e.g. authorization header.
+	Headers map[string]string
+	// Client is used to send requests. Default is http.DefaultClient.
+	Client *http.Client
+	// Resource is used as attributes of resource. e.g. {"service.name": "app"}
+	Resource map[string]interface{}
+	// Record is option of LogRecord conversion.
+	Record RecordConfig
+
+	// BatchSize is max number of records per request. Default is 512.
+	BatchSize int
+	// FlushInterval is interval of background flush. Default is 5s.
+	// If negative, only the full batches are sent in the background.
+	FlushInterval time.Duration
+	// Timeout is timeout of a request including retries. Default is 30s.
+	Timeout time.Duration
+	// MaxQueueSize is max number of buffered records. Default is 16 times BatchSize.
+	// While the endpoint is slow or down, the records exceeding MaxQueueSize are dropped
+	// and the count is reported to ErrorHandler.
+	MaxQueueSize int
+
+	// MaxRetries is max count of retry. Default is 5 and negative disables retry.
+	MaxRetries int
+	// InitialBackoff is wait time of first retry. Default is 100ms.
+	InitialBackoff time.Duration
+	// MaxBackoff is max wait time of retry. Default is 10s.
+	MaxBackoff time.Duration
+	// RetryableStatus are the http status codes retried. Default is 429, 502, 503 and 504 of the OTLP specification.
+	RetryableStatus []int
+
+	// ErrorHandler is called when the background send fails or the records are dropped.
+	// Default writes the error to os.Stderr.
+	ErrorHandler func(err error)
+}
+
+// Exporter is a encoder that posts batches of LogRecord to an OTLP/HTTP endpoint.
+// The batches are sent in the background, so Encode doesn't wait for the endpoint.
+// Exporter must be closed to send buffered records.
+type Exporter struct {
+	conf    ExporterConfig
+	policy  retry.Policy
+	header  http.Header
+	batcher *batch.Batcher
+}
+
+// NewExporter create a new Exporter.
+func NewExporter(conf ExporterConfig) *Exporter {
+	if conf.Endpoint == "" {
+		conf.Endpoint = "http://localhost:4318/v1/logs"
+	}
+	if conf.BatchSize <= 0 {
+		conf.BatchSize = 512
+	}
+	if conf.ErrorHandler == nil {
+		conf.ErrorHandler = func(err error) {
+			fmt.Fprintf(os.Stderr, "otelopt: export logs: %v\n", err)
+		}
+	}
+	conf.Record = conf.Record.withDefault()
+
+	e := &Exporter{
+		conf: conf,
+		policy: retry.Policy{
+			MaxRetries:      conf.MaxRetries,
+			InitialBackoff:  conf.InitialBackoff,
+			MaxBackoff:      conf.MaxBackoff,
+			RetryableStatus: conf.RetryableStatus,
+		},
+		header: retry.JSONHeader(conf.Headers),
+	}
+	e.batcher = batch.New(batch.Config{
+		BatchSize:     conf.BatchSize,
+		FlushInterval: conf.FlushInterval,
+		Timeout:       conf.Timeout,
+		MaxQueueSize:  conf.MaxQueueSize,
+		ErrorHandler:  conf.ErrorHandler,
+	}, e.send)
+	return e
+}
+
+// Encode implements logplug.Encoder.
+// The record is sent in the background when the number of records reaches BatchSize.
+func (e *Exporter) Encode(p *logplug.Plug, m *logplug.MessageElement) error {
+	e.batcher.Add(NewLogRecord(p, m, e.conf.Record))
+	return nil
+}
+
+// Flush sends buffered records.
+func (e *Exporter) Flush(ctx context.Context) error {
+	return e.batcher.Flush(ctx)
+}
+
+// Close stops the background flush and sends buffered records.
+func (e *Exporter) Close() error {
+	return e.batcher.Close()
+}
+
+func (e *Exporter) send(ctx context.Context, items []interface{}) error {
+	records := make([]LogRecord, len(items))
+	for i, item := range items {
+		records[i] = item.(LogRecord)
+	}
+
+	body, err := json.Marshal(NewExportRequest(e.conf.Resource, records))
+	if err != nil {
+		return err
+	}
+	return e.policy.Post(ctx, e.conf.Client, e.conf.Endpoint, e.header, body)
+}
@@ -0,0 +1,196 @@
+package otelopt_test
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/komem3/logplug"
+	"github.com/komem3/logplug/otelopt"
+)
+
+type collector struct {
+	mu       sync.Mutex
+	fails    int
+	requests []otelopt.ExportRequest
+}
+
+func (c *collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if r.Header.Get("Authorization") != "token" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	if c.fails > 0 {
+		c.fails--
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	var req otelopt.ExportRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	c.requests = append(c.requests, req)
+}
+
+func (c *collector) bodies() [][]string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var bodies [][]string
+	for _, req := range c.requests {
+		var batch []string
+		for _, record := range req.ResourceLogs[0].ScopeLogs[0].LogRecords {
+			batch = append(batch, *record.Body.StringValue)
+		}
+		bodies = append(bodies, batch)
+	}
+	return bodies
+}
+
+func TestExporter(t *testing.T) {
+	for _, tt := range []struct {
+		name       string
+		fails      int
+		maxRetries int
+		messages   []string
+		want       [][]string
+		wantErr    bool
+	}{
+		{
+			name:     "batch by size",
+			messages: []string{"1", "2", "3", "4", "5"},
+			want:     [][]string{{"1", "2"}, {"3", "4"}, {"5"}},
+		},
+		{
+			name:     "retry",
+			fails:    2,
+			messages: []string{"1", "2"},
+			want:     [][]string{{"1", "2"}},
+		},
+		{
+			name:       "give up",
+			fails:      3,
+			maxRetries: 1,
+			messages:   []string{"1"},
+			wantErr:    true,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			c := &collector{fails: tt.fails}
+			server := httptest.NewServer(c)
+			defer server.Close()
+
+			exporter := otelopt.NewExporter(otelopt.ExporterConfig{
+				Endpoint:       server.URL,
+				Headers:        map[string]string{"Authorization": "token"},
+				BatchSize:      2,
+				FlushInterval:  -1,
+				MaxRetries:     tt.maxRetries,
+				InitialBackoff: time.Millisecond,
+			})
+			l := log.New(logplug.NewPlug(exporter), "", 0)
+			for _, msg := range tt.messages {
+				l.Print(msg)
+			}
+
+			err := exporter.Close()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := c.bodies(); !equalBatches(got, tt.want) {
+				t.Errorf("mismatch batches\ngot:  %v\nwant: %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExporter_FlushInterval(t *testing.T) {
+	c := &collector{}
+	server := httptest.NewServer(c)
+	defer server.Close()
+
+	exporter := otelopt.NewExporter(otelopt.ExporterConfig{
+		Endpoint:      server.URL,
+		Headers:       map[string]string{"Authorization": "token"},
+		FlushInterval: 10 * time.Millisecond,
+	})
+	defer exporter.Close()
+
+	log.New(logplug.NewPlug(exporter), "", 0).Print("background")
+
+	deadline := time.Now().Add(time.Second)
+	for len(c.bodies()) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("records are not flushed")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestExporter_NonBlocking(t *testing.T) {
+	c := &collector{}
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		c.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	exporter := otelopt.NewExporter(otelopt.ExporterConfig{
+		Endpoint:      server.URL,
+		Headers:       map[string]string{"Authorization": "token"},
+		BatchSize:     1,
+		FlushInterval: -1,
+	})
+	l := log.New(logplug.NewPlug(exporter), "", 0)
+
+	logged := make(chan struct{})
+	go func() {
+		defer close(logged)
+		for _, msg := range []string{"1", "2", "3"} {
+			l.Print(msg)
+		}
+	}()
+	select {
+	case <-logged:
+	case <-time.After(time.Second):
+		t.Fatal("logging is blocked by the endpoint")
+	}
+
+	close(release)
+	if err := exporter.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := c.bodies(), [][]string{{"1"}, {"2"}, {"3"}}; !equalBatches(got, want) {
+		t.Errorf("mismatch batches\ngot:  %v\nwant: %v", got, want)
+	}
+}
+
+func equalBatches(a, b [][]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if len(a[i]) != len(b[i]) {
+			return false
+		}
+		for j := range a[i] {
+			if a[i][j] != b[i][j] {
+				return false
+			}
+		}
+	}
+	return true
+}
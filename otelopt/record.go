@@ -0,0 +1,335 @@
+package otelopt
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/komem3/logplug"
+	"github.com/komem3/logplug/internal/traceparent"
+)
+
+// SeverityNumber is severity number of LogRecord.
+type SeverityNumber int
+
+// SeverityNumber values defined by the log data model.
+const (
+	SeverityUnspecified SeverityNumber = 0
+	SeverityTrace       SeverityNumber = 1
+	SeverityDebug       SeverityNumber = 5
+	SeverityInfo        SeverityNumber = 9
+	SeverityInfo2       SeverityNumber = 10
+	SeverityWarn        SeverityNumber = 13
+	SeverityError       SeverityNumber = 17
+	SeverityFatal       SeverityNumber = 21
+	SeverityFatal3      SeverityNumber = 23
+	SeverityFatal4      SeverityNumber = 24
+)
+
+// SeverityMap maps level to SeverityNumber.
+// The key is compared in upper case.
+type SeverityMap map[logplug.Level]SeverityNumber
+
+// DefaultSeverityMap is a mapping of common level names.
+// This follows the syslog mapping of the log data model.
+var DefaultSeverityMap = SeverityMap{
+	"TRACE":     SeverityTrace,
+	"DBG":       SeverityDebug,
+	"DEBUG":     SeverityDebug,
+	"INFO":      SeverityInfo,
+	"NOTICE":    SeverityInfo2,
+	"WARN":      SeverityWarn,
+	"WARNING":   SeverityWarn,
+	"ERR":       SeverityError,
+	"ERROR":     SeverityError,
+	"CRITICAL":  SeverityFatal,
+	"FATAL":     SeverityFatal,
+	"ALERT":     SeverityFatal3,
+	"EMERGENCY": SeverityFatal4,
+}
+
+// RecordConfig is option of LogRecord conversion.
+type RecordConfig struct {
+	// LevelField is field name of level. Default is "level".
+	LevelField string
+	// TraceIDFields are field names of trace id. Default is "trace_id" and "traceId".
+	TraceIDFields []string
+	// SpanIDFields are field names of span id. Default is "span_id" and "spanId".
+	SpanIDFields []string
+	// TraceParentField is field name of W3C traceparent. Default is "traceparent".
+	TraceParentField string
+	// Severity maps level to SeverityNumber. Default is DefaultSeverityMap.
+	Severity SeverityMap
+}
+
+func (c RecordConfig) withDefault() RecordConfig {
+	if c.LevelField == "" {
+		c.LevelField = "level"
+	}
+	if c.TraceIDFields == nil {
+		c.TraceIDFields = []string{"trace_id", "traceId"}
+	}
+	if c.SpanIDFields == nil {
+		c.SpanIDFields = []string{"span_id", "spanId"}
+	}
+	if c.TraceParentField == "" {
+		c.TraceParentField = traceparent.Header
+	}
+	if c.Severity == nil {
+		c.Severity = DefaultSeverityMap
+	}
+	return c
+}
+
+// LogRecord is LogRecord of OTLP JSON.
+type LogRecord struct {
+	TimeUnixNano         string         `json:"timeUnixNano,omitempty"`
+	ObservedTimeUnixNano string         `json:"observedTimeUnixNano,omitempty"`
+	SeverityNumber       SeverityNumber `json:"severityNumber,omitempty"`
+	SeverityText         string         `json:"severityText,omitempty"`
+	Body                 *AnyValue      `json:"body,omitempty"`
+	Attributes           []KeyValue     `json:"attributes,omitempty"`
+	TraceID              string         `json:"traceId,omitempty"`
+	SpanID               string         `json:"spanId,omitempty"`
+	Flags                uint32         `json:"flags,omitempty"`
+}
+
+// KeyValue is key value pair of attributes.
+type KeyValue struct {
+	Key   string   `json:"key"`
+	Value AnyValue `json:"value"`
+}
+
+// AnyValue is value of body and attributes.
+type AnyValue struct {
+	StringValue *string       `json:"stringValue,omitempty"`
+	BoolValue   *bool         `json:"boolValue,omitempty"`
+	IntValue    *string       `json:"intValue,omitempty"`
+	DoubleValue *float64      `json:"doubleValue,omitempty"`
+	ArrayValue  *ArrayValue   `json:"arrayValue,omitempty"`
+	KvlistValue *KeyValueList `json:"kvlistValue,omitempty"`
+}
+
+// ArrayValue is list of AnyValue.
+type ArrayValue struct {
+	Values []AnyValue `json:"values"`
+}
+
+// KeyValueList is list of KeyValue.
+type KeyValueList struct {
+	Values []KeyValue `json:"values"`
+}
+
+// ExportRequest is body of ExportLogsServiceRequest.
+type ExportRequest struct {
+	ResourceLogs []ResourceLogs `json:"resourceLogs"`
+}
+
+// ResourceLogs is logs of a resource.
+type ResourceLogs struct {
+	Resource  Resource    `json:"resource"`
+	ScopeLogs []ScopeLogs `json:"scopeLogs"`
+}
+
+// Resource is resource of logs.
+type Resource struct {
+	Attributes []KeyValue `json:"attributes,omitempty"`
+}
+
+// ScopeLogs is logs of an instrumentation scope.
+type ScopeLogs struct {
+	Scope      Scope       `json:"scope"`
+	LogRecords []LogRecord `json:"logRecords"`
+}
+
+// Scope is instrumentation scope.
+type Scope struct {
+	Name string `json:"name"`
+}
+
+// ScopeName is name of instrumentation scope.
+const ScopeName = "github.com/komem3/logplug"
+
+// NewExportRequest create ExportRequest of records.
+func NewExportRequest(resource map[string]interface{}, records []LogRecord) *ExportRequest {
+	return &ExportRequest{
+		ResourceLogs: []ResourceLogs{{
+			Resource: Resource{Attributes: attributes(resource)},
+			ScopeLogs: []ScopeLogs{{
+				Scope:      Scope{Name: ScopeName},
+				LogRecords: records,
+			}},
+		}},
+	}
+}
+
+// NewLogRecord converts m to LogRecord.
+// The record does not refer m, so m can be reused after this.
+func NewLogRecord(p *logplug.Plug, m *logplug.MessageElement, conf RecordConfig) LogRecord {
+	conf = conf.withDefault()
+
+	record := LogRecord{
+		ObservedTimeUnixNano: strconv.FormatInt(time.Now().UnixNano(), 10),
+	}
+	used := map[string]bool{
+		p.MessageField():   true,
+		p.TimestampField(): true,
+		p.LocationField():  true,
+		conf.LevelField:    true,
+	}
+
+	if t := m.GetTime(p.TimestampField()); !t.IsZero() {
+		record.TimeUnixNano = strconv.FormatInt(t.UnixNano(), 10)
+	} else if _, ok := m.Elements()[p.TimestampField()]; ok {
+		used[p.TimestampField()] = false
+	}
+
+	body := m.GetString(p.MessageField())
+	record.Body = &AnyValue{StringValue: &body}
+
+	if level := m.GetString(conf.LevelField); level != "" {
+		record.SeverityText = level
+		record.SeverityNumber = conf.Severity[strings.ToUpper(level)]
+	}
+
+	if tp, ok := traceparent.Parse(m.GetString(conf.TraceParentField)); ok {
+		record.TraceID, record.SpanID = tp.TraceID, tp.SpanID
+		if tp.Sampled {
+			record.Flags = 1
+		}
+		used[conf.TraceParentField] = true
+	}
+	for _, field := range conf.TraceIDFields {
+		if id := strings.ToLower(m.GetString(field)); traceparent.ValidTraceID(id) {
+			record.TraceID = id
+			used[field] = true
+		}
+	}
+	for _, field := range conf.SpanIDFields {
+		if id := strings.ToLower(m.GetString(field)); traceparent.ValidSpanID(id) {
+			record.SpanID = id
+			used[field] = true
+		}
+	}
+
+	if location := m.GetString(p.LocationField()); location != "" {
+		index := strings.LastIndexByte(location, ':')
+		line, err := strconv.Atoi(location[index+1:])
+		if index == -1 || err != nil {
+			record.Attributes = append(record.Attributes, stringAttribute("code.filepath", location))
+		} else {
+			record.Attributes = append(record.Attributes,
+				stringAttribute("code.filepath", location[:index]),
+				KeyValue{Key: "code.lineno", Value: anyValue(line)},
+			)
+		}
+	}
+
+	elements := make(map[string]interface{}, len(m.Elements()))
+	for key, v := range m.Elements() {
+		if !used[key] {
+			elements[key] = v
+		}
+	}
+	record.Attributes = append(record.Attributes, attributes(elements)...)
+
+	return record
+}
+
+func stringAttribute(key, v string) KeyValue {
+	return KeyValue{Key: key, Value: AnyValue{StringValue: &v}}
+}
+
+func attributes(m map[string]interface{}) []KeyValue {
+	if len(m) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	kvs := make([]KeyValue, 0, len(m))
+	for _, key := range keys {
+		kvs = append(kvs, KeyValue{Key: key, Value: anyValue(m[key])})
+	}
+	return kvs
+}
+
+func anyValue(v interface{}) AnyValue {
+	switch v := v.(type) {
+	case nil:
+		return AnyValue{}
+	case string:
+		return AnyValue{StringValue: &v}
+	case bool:
+		return AnyValue{BoolValue: &v}
+	case int:
+		return intValue(int64(v))
+	case int32:
+		return intValue(int64(v))
+	case int64:
+		return intValue(v)
+	case uint32:
+		return intValue(int64(v))
+	case float32:
+		f := float64(v)
+		return AnyValue{DoubleValue: &f}
+	case float64:
+		return AnyValue{DoubleValue: &v}
+	case time.Time:
+		s := v.Format(time.RFC3339Nano)
+		return AnyValue{StringValue: &s}
+	case []interface{}:
+		values := make([]AnyValue, 0, len(v))
+		for _, e := range v {
+			values = append(values, anyValue(e))
+		}
+		return AnyValue{ArrayValue: &ArrayValue{Values: values}}
+	case []string:
+		values := make([]AnyValue, 0, len(v))
+		for _, e := range v {
+			values = append(values, anyValue(e))
+		}
+		return AnyValue{ArrayValue: &ArrayValue{Values: values}}
+	case map[string]interface{}:
+		return AnyValue{KvlistValue: &KeyValueList{Values: attributes(v)}}
+	case map[string]string:
+		m := make(map[string]interface{}, len(v))
+		for key, e := range v {
+			m[key] = e
+		}
+		return AnyValue{KvlistValue: &KeyValueList{Values: attributes(m)}}
+	case fmt.Stringer:
+		s := v.String()
+		return AnyValue{StringValue: &s}
+	case error:
+		s := v.Error()
+		return AnyValue{StringValue: &s}
+	}
+
+	// other values are converted via json.
+	b, err := json.Marshal(v)
+	if err != nil {
+		s := fmt.Sprint(v)
+		return AnyValue{StringValue: &s}
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		s := string(b)
+		return AnyValue{StringValue: &s}
+	}
+	if f, ok := decoded.(float64); ok && f == float64(int64(f)) {
+		return intValue(int64(f))
+	}
+	return anyValue(decoded)
+}
+
+func intValue(i int64) AnyValue {
+	s := strconv.FormatInt(i, 10)
+	return AnyValue{IntValue: &s}
+}
@@ -0,0 +1,89 @@
+package otelopt_test
+
+import (
+	"bytes"
+	"log"
+	"regexp"
+	"testing"
+
+	"github.com/komem3/logplug"
+	"github.com/komem3/logplug/otelopt"
+)
+
+func TestNewPlug(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		prefix string
+		flag   int
+		want   string
+	}{
+		{
+			name: "[INFO]body and severity",
+			want: `^{"resourceLogs":\[{"resource":{"attributes":\[{"key":"service.name","value":{"stringValue":"test"}}\]},"scopeLogs":\[{"scope":{"name":"github.com/komem3/logplug"},"logRecords":\[{"observedTimeUnixNano":"[0-9]+","severityNumber":9,"severityText":"INFO","body":{"stringValue":"body and severity"}}\]}\]}\]}`,
+		},
+		{
+			name: "[ERR]attributes", prefix: "[user:1000][admin:true]",
+			want: `"severityNumber":17,"severityText":"ERR","body":{"stringValue":"attributes"},"attributes":\[{"key":"admin","value":{"boolValue":true}},{"key":"user","value":{"stringValue":"1000"}}\]}`,
+		},
+		{
+			name:   "trace id",
+			prefix: "[trace_id:4BF92F3577B34DA6A3CE929D0E0E4736][span_id:00f067aa0ba902b7]",
+			want:   `"body":{"stringValue":"trace id"},"traceId":"4bf92f3577b34da6a3ce929d0e0e4736","spanId":"00f067aa0ba902b7"}`,
+		},
+		{
+			name:   "traceparent",
+			prefix: "[traceparent:00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01]",
+			want:   `"body":{"stringValue":"traceparent"},"traceId":"4bf92f3577b34da6a3ce929d0e0e4736","spanId":"00f067aa0ba902b7","flags":1}`,
+		},
+		{
+			name: "invalid trace id", prefix: "[trace_id:xyz]",
+			want: `"attributes":\[{"key":"trace_id","value":{"stringValue":"xyz"}}\]}`,
+		},
+		{
+			name: "with date and file", flag: log.Ldate | log.Lshortfile,
+			want: `"timeUnixNano":"[0-9]+","observedTimeUnixNano":"[0-9]+","severityNumber":9,"severityText":"INFO","body":{"stringValue":"with date and file"},"attributes":\[{"key":"code.filepath","value":{"stringValue":"record_test.go"}},{"key":"code.lineno","value":{"intValue":"[0-9]+"}}\]}`,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+			log.New(otelopt.NewPlug(&buf, map[string]interface{}{"service.name": "test"}, otelopt.RecordConfig{},
+				logplug.LogFlag(tt.flag),
+				logplug.Hooks(logplug.LevelHook(logplug.LevelConfig{
+					Levels:  []logplug.Level{"DBG", "INFO", "ERR"},
+					Default: "INFO",
+				})),
+			), tt.prefix, tt.flag).Print(tt.name)
+
+			match, err := regexp.Match(tt.want, buf.Bytes())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !match {
+				t.Errorf("mismatch output\ngot:  %swant: %s", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestNewPlug_nilValue(t *testing.T) {
+	var buf bytes.Buffer
+	log.New(otelopt.NewPlug(&buf, nil, otelopt.RecordConfig{},
+		logplug.Hooks(logplug.StaticFieldsHook(map[string]interface{}{
+			"nil":    nil,
+			"nested": struct{ A *int }{},
+			"map":    map[string]interface{}{"k": nil},
+		})),
+	), "", 0).Print("nil value")
+
+	want := `"attributes":\[{"key":"map","value":{"kvlistValue":{"values":\[{"key":"k","value":{}}\]}}},{"key":"nested","value":{"kvlistValue":{"values":\[{"key":"A","value":{}}\]}}},{"key":"nil","value":{}}\]}`
+	match, err := regexp.MatchString(want, buf.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !match {
+		t.Errorf("mismatch output\ngot:  %swant: %s", buf.String(), want)
+	}
+}
@@ -1,10 +1,15 @@
 package logplug
 
 import (
+	"context"
+	"io"
 	"log"
+	"runtime"
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/komem3/logplug/internal/caller"
 )
 
 // Encoder defines a encode for each field in the log.
@@ -26,16 +31,44 @@ type Hook func(enc Encoder) Encoder
 // MessageElement store elements of message.
 type MessageElement struct {
 	elements map[string]interface{}
+	ctx      context.Context
+	// errorID is id of the error written by Err.
+	errorID string
+	// pcs is the call stack captured while Plug writes the message, and frames is the resolved frames of pcs.
+	pcs     []uintptr
+	frames  []runtime.Frame
+	writing bool
 }
 
+// callerDepth is max number of the frames captured by Plug.
+const callerDepth = 64
+
 var messageElementPool = sync.Pool{
 	New: func() interface{} {
 		return &MessageElement{
 			elements: make(map[string]interface{}, 3),
+			pcs:      make([]uintptr, 0, callerDepth),
 		}
 	},
 }
 
+func newMessageElement() *MessageElement {
+	return messageElementPool.Get().(*MessageElement)
+}
+
+// release clears elements and puts m back to the pool.
+func (m *MessageElement) release() {
+	for key := range m.elements {
+		delete(m.elements, key)
+	}
+	m.ctx = nil
+	m.errorID = ""
+	m.pcs = m.pcs[:0]
+	m.frames = nil
+	m.writing = false
+	messageElementPool.Put(m)
+}
+
 func (m *MessageElement) GetString(key string) string {
 	v, _ := m.elements[key].(string)
 	return v
@@ -71,15 +104,48 @@ func (m *MessageElement) Elements() map[string]interface{} {
 	return m.elements
 }
 
+// Context returns the context of the logger created by FromContext.
+// If the logger has no context, context.Background is returned.
+func (m *MessageElement) Context() context.Context {
+	if m.ctx == nil {
+		return context.Background()
+	}
+	return m.ctx
+}
+
+// Frames returns the call stack from the log call site.
+// The frames of the writers between log and Plug, and the frames of logplug are skipped.
+// The stack is captured once on the first call while Plug writes the message,
+// and kept when the message is encoded later by the hooks like DedupHook.
+func (m *MessageElement) Frames() []runtime.Frame {
+	if m.frames == nil {
+		m.capture()
+		m.frames = caller.Frames(m.pcs)
+	}
+	return m.frames
+}
+
+// capture captures the call stack if Plug is writing m and the stack isn't captured yet.
+func (m *MessageElement) capture() {
+	if m.writing && len(m.pcs) == 0 {
+		m.pcs = m.pcs[:runtime.Callers(2, m.pcs[:cap(m.pcs)])]
+	}
+}
+
 // Plug is standard log plug.
 type Plug struct {
 	encoder Encoder
 	hooks   []Hook
+	closers []io.Closer
 
 	messageField   string
 	timeStampField string
 	locationField  string
 	flag           int
+	timeFormatter  TimeFormatter
+	timestampMode  TimestampMode
+	clock          func() time.Time
+	callerConfig   *CallerConfig
 }
 
 // NewPlug create new log plug.
@@ -88,14 +154,24 @@ func NewPlug(encoder Encoder, opts ...Option) *Plug {
 		messageField:   "message",
 		timeStampField: "timestamp",
 		locationField:  "location",
+		clock:          time.Now,
 	}
 
 	for _, opt := range opts {
 		opt(p)
 	}
 
+	if c, ok := encoder.(io.Closer); ok {
+		p.closers = append(p.closers, c)
+	}
+	if p.timeFormatter != nil {
+		encoder = TimestampFormatHook(p.timeFormatter)(encoder)
+	}
 	for i := len(p.hooks) - 1; i >= 0; i-- {
 		encoder = p.hooks[i](encoder)
+		if c, ok := encoder.(io.Closer); ok {
+			p.closers = append(p.closers, c)
+		}
 	}
 	p.encoder = encoder
 
@@ -104,9 +180,22 @@ func NewPlug(encoder Encoder, opts ...Option) *Plug {
 
 // Write implements io.Writer.
 func (p *Plug) Write(msgb []byte) (n int, err error) {
-	mel := messageElementPool.Get().(*MessageElement)
+	return p.write(nil, msgb, nil)
+}
+
+// write parses msgb and encodes it with ctx and the typed fields.
+// The fields don't overwrite the fields parsed from msgb.
+func (p *Plug) write(ctx context.Context, msgb []byte, fields []field) (n int, err error) {
+	mel := newMessageElement()
+	mel.ctx = ctx
+	mel.writing = true
 	msg := string(msgb)
 
+	var now time.Time
+	if p.timestampMode != ParsedTimestamp {
+		now = p.clock()
+	}
+
 	// log flag process
 	if p.flag&log.Lmsgprefix != 0 {
 		t, index := p.extractTimestamp(msg)
@@ -183,16 +272,52 @@ func (p *Plug) Write(msgb []byte) (n int, err error) {
 	msg = strings.TrimLeft(strings.TrimRight(msg, "\n"), " ")
 	mel.AddString(p.messageField, msg)
 
-	if err := p.encoder.Encode(p, mel); err != nil {
-		return 0, err
+	if id := mel.GetString(ErrorIDField); id != "" {
+		delete(mel.elements, ErrorIDField)
+		mel.errorID = id
+	}
+
+	for _, f := range fields {
+		if _, ok := mel.elements[f.key]; !ok {
+			mel.elements[f.key] = f.value
+		}
 	}
-	for key := range mel.elements {
-		delete(mel.elements, key)
+
+	if p.callerConfig != nil {
+		p.setCaller(mel)
 	}
-	messageElementPool.Put(mel)
+
+	switch p.timestampMode {
+	case WriteTimestamp:
+		mel.Set(p.timeStampField, now)
+	case PreferParsedTimestamp:
+		if mel.GetTime(p.timeStampField).IsZero() {
+			mel.Set(p.timeStampField, now)
+		}
+	}
+
+	err = p.encoder.Encode(p, mel)
+	mel.writing = false
+	if err != nil {
+		return 0, err
+	}
+	mel.release()
 	return len(msgb), nil
 }
 
+// Close closes the encoder and the hooks implementing io.Closer.
+// The hooks buffering messages flush them to the next encoder, so they are closed from the outermost.
+// Close returns the first error.
+func (p *Plug) Close() error {
+	var err error
+	for i := len(p.closers) - 1; i >= 0; i-- {
+		if cerr := p.closers[i].Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
 func (p *Plug) MessageField() string {
 	return p.messageField
 }
@@ -249,15 +374,32 @@ func (p *Plug) extractTimestamp(msg string) (time.Time, int) {
 	return time.Time{}, 0
 }
 
+// extractFile returns "file:line" before ": ".
+// The file may contain colons like "C:/src/main.go:10: message".
 func (p *Plug) extractFile(msg string) string {
-	if p.flag&(log.Lshortfile|log.Llongfile) != 0 {
-		index := p.secondIndex(msg, ':')
-		if index <= 0 {
+	if p.flag&(log.Lshortfile|log.Llongfile) == 0 {
+		return ""
+	}
+	for start := 0; ; {
+		index := strings.Index(msg[start:], ": ")
+		if index == -1 {
 			return ""
 		}
-		return msg[:index]
+		index += start
+		if isFileLine(msg[:index]) {
+			return msg[:index]
+		}
+		start = index + 1
+	}
+}
+
+// isFileLine reports whether s ends with ":<digits>" after the file name.
+func isFileLine(s string) bool {
+	i := len(s)
+	for i > 0 && '0' <= s[i-1] && s[i-1] <= '9' {
+		i--
 	}
-	return ""
+	return i != len(s) && i > 1 && s[i-1] == ':'
 }
 
 func (p *Plug) parseBool(msg string) (b bool, ok bool) {
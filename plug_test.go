@@ -6,6 +6,7 @@ import (
 	"regexp"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/komem3/logplug"
 )
@@ -116,3 +117,70 @@ func TestJSONPlug_WithLogFlag(t *testing.T) {
 		})
 	}
 }
+
+func TestJSONPlug_TimestampSource(t *testing.T) {
+	now := func() time.Time {
+		return time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+
+	for _, tt := range []struct {
+		name string
+		mode logplug.TimestampMode
+		flag int
+		msg  string
+		want string
+	}{
+		{
+			name: "parsed without date",
+			mode: logplug.ParsedTimestamp,
+			msg:  "message",
+			want: `{"message":"message"}`,
+		},
+		{
+			name: "write without date",
+			mode: logplug.WriteTimestamp,
+			msg:  "message",
+			want: `{"message":"message","timestamp":"2021-01-01T00:00:00Z"}`,
+		},
+		{
+			name: "write with date",
+			mode: logplug.WriteTimestamp,
+			flag: log.LstdFlags,
+			msg:  "2020/12/31 10:00:00 message",
+			want: `{"message":"message","timestamp":"2021-01-01T00:00:00Z"}`,
+		},
+		{
+			name: "prefer parsed without date",
+			mode: logplug.PreferParsedTimestamp,
+			flag: log.Lshortfile,
+			msg:  "main.go:10: message",
+			want: `{"location":"main.go:10","message":"message","timestamp":"2021-01-01T00:00:00Z"}`,
+		},
+		{
+			name: "prefer parsed with date",
+			mode: logplug.PreferParsedTimestamp,
+			flag: log.LstdFlags,
+			msg:  "2020/12/31 10:00:00 message",
+			want: `{"message":"message","timestamp":"2020-12-31T10:00:00Z"}`,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+			plug := logplug.NewJSONPlug(&buf,
+				logplug.LogFlag(tt.flag),
+				logplug.TimestampSource(tt.mode),
+				logplug.Clock(now),
+			)
+			if _, err := plug.Write([]byte(tt.msg)); err != nil {
+				t.Fatal(err)
+			}
+
+			if strings.TrimRight(buf.String(), "\n") != tt.want {
+				t.Errorf("mismatch output\ngot:  %swant: %s", buf.String(), tt.want)
+			}
+		})
+	}
+}
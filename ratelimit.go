@@ -0,0 +1,196 @@
+package logplug
+
+import (
+	"sync"
+	"time"
+)
+
+// RateLimit is limit of token bucket.
+type RateLimit struct {
+	// Rate is number of records per second.
+	// If Rate is 0 and Burst is positive, the bucket is never refilled,
+	// so Burst is the cap of records for the lifetime of the Plug.
+	Rate float64
+	// Burst is max number of records at once. Default is ceil of Rate.
+	Burst int
+}
+
+func (l RateLimit) enabled() bool {
+	return l.Rate > 0 || l.Burst > 0
+}
+
+// RateLimitConfig is option of RateLimitHook.
+type RateLimitConfig struct {
+	// Levels are global limits per level. The levels not in Levels are not limited.
+	Levels map[Level]RateLimit
+	// Location is limit per source location. If zero, the location is not limited.
+	Location RateLimit
+	// LevelField is field name of level. Default is "level".
+	LevelField string
+
+	// NoticeInterval is interval of the notice of suppressed records. Default is 1 minute.
+	// The notice is emitted with the next record after the interval,
+	// or by a timer at the interval after the first suppressed record if no record follows.
+	// If negative, the notice is disabled.
+	NoticeInterval time.Duration
+	// NoticeField is field name of the counters in the notice. Default is "suppressed".
+	NoticeField string
+
+	// Clock returns current time. Default is time.Now.
+	Clock func() time.Time
+}
+
+// rateLimitLocations is max number of location buckets.
+// If exceeded, the buckets are reset.
+const rateLimitLocations = 10000
+
+type tokenBucket struct {
+	limit  RateLimit
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(limit RateLimit, now time.Time) *tokenBucket {
+	if limit.Burst <= 0 {
+		limit.Burst = int(limit.Rate)
+		if float64(limit.Burst) < limit.Rate {
+			limit.Burst++
+		}
+	}
+	return &tokenBucket{limit: limit, tokens: float64(limit.Burst), last: now}
+}
+
+func (b *tokenBucket) allow(now time.Time) bool {
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens += elapsed.Seconds() * b.limit.Rate
+		if b.tokens > float64(b.limit.Burst) {
+			b.tokens = float64(b.limit.Burst)
+		}
+		b.last = now
+	}
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+type rateLimiter struct {
+	conf RateLimitConfig
+	enc  Encoder
+
+	mu        sync.Mutex
+	plug      *Plug
+	levels    map[Level]*tokenBucket
+	locations map[string]*tokenBucket
+	notice    *dropSummary
+}
+
+// RateLimitHook drops records exceeding the limits of token buckets per level and per source location.
+// The location is the location field set by log.Lshortfile or log.Llongfile.
+//	logplug.RateLimitHook(logplug.RateLimitConfig{
+//		Levels:   map[logplug.Level]logplug.RateLimit{"DEBUG": {Rate: 100}},
+//		Location: logplug.RateLimit{Rate: 10},
+//	})
+//
+// The counters of the suppressed records are emitted periodically and on Plug.Close as a notice.
+// The error of the notice emitted by the timer is returned by the next Encode or Close.
+//	{"message":"logplug: 12 records suppressed","suppressed":{"level:DEBUG":10,"location:main.go:12":2}}
+//
+// RateLimitHook should be set after LevelHook to use the level.
+func RateLimitHook(config RateLimitConfig) Hook {
+	if config.LevelField == "" {
+		config.LevelField = "level"
+	}
+	if config.NoticeInterval == 0 {
+		config.NoticeInterval = time.Minute
+	}
+	if config.NoticeField == "" {
+		config.NoticeField = "suppressed"
+	}
+	if config.Clock == nil {
+		config.Clock = time.Now
+	}
+
+	return func(enc Encoder) Encoder {
+		now := config.Clock()
+		r := &rateLimiter{
+			conf:      config,
+			enc:       enc,
+			levels:    make(map[Level]*tokenBucket, len(config.Levels)),
+			locations: make(map[string]*tokenBucket),
+			notice:    newDropSummary(config.NoticeInterval, now),
+		}
+		for level, limit := range config.Levels {
+			if limit.enabled() {
+				r.levels[level] = newTokenBucket(limit, now)
+			}
+		}
+		return r
+	}
+}
+
+// Encode implements Encoder.
+func (r *rateLimiter) Encode(p *Plug, m *MessageElement) error {
+	now := r.conf.Clock()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.plug = p
+	if r.notice.due(now) {
+		if err := r.emitNotice(p); err != nil {
+			return err
+		}
+	}
+
+	if level := m.GetString(r.conf.LevelField); level != "" {
+		if b, ok := r.levels[level]; ok && !b.allow(now) {
+			r.notice.add("level:"+level, r.fire)
+			return r.notice.takeErr()
+		}
+	}
+	if location := m.GetString(p.LocationField()); location != "" && r.conf.Location.enabled() {
+		b, ok := r.locations[location]
+		if !ok {
+			if len(r.locations) >= rateLimitLocations {
+				r.locations = make(map[string]*tokenBucket)
+			}
+			b = newTokenBucket(r.conf.Location, now)
+			r.locations[location] = b
+		}
+		if !b.allow(now) {
+			r.notice.add("location:"+location, r.fire)
+			return r.notice.takeErr()
+		}
+	}
+	if err := r.enc.Encode(p, m); err != nil {
+		return err
+	}
+	return r.notice.takeErr()
+}
+
+// fire emits the notice from the timer.
+func (r *rateLimiter) fire() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.notice.setErr(r.emitNotice(r.plug))
+}
+
+// Close emits the notice of suppressed records.
+func (r *rateLimiter) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.plug == nil {
+		return nil
+	}
+	if err := r.emitNotice(r.plug); err != nil {
+		return err
+	}
+	return r.notice.takeErr()
+}
+
+func (r *rateLimiter) emitNotice(p *Plug) error {
+	return r.notice.emit(p, r.enc, "records suppressed", r.conf.NoticeField)
+}
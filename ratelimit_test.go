@@ -0,0 +1,93 @@
+package logplug_test
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/komem3/logplug"
+)
+
+func TestRateLimitHook(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)}
+
+	var buf bytes.Buffer
+	plug := logplug.NewJSONPlug(&buf,
+		logplug.LogFlag(log.Lshortfile),
+		logplug.Hooks(
+			logplug.LevelHook(logplug.LevelConfig{Levels: []logplug.Level{"DEBUG", "INFO"}}),
+			logplug.RateLimitHook(logplug.RateLimitConfig{
+				Levels:         map[logplug.Level]logplug.RateLimit{"DEBUG": {Rate: 1, Burst: 2}},
+				Location:       logplug.RateLimit{Rate: 1},
+				NoticeInterval: 10 * time.Second,
+				Clock:          clock.Now,
+			}),
+		),
+	)
+	write := func(s string) {
+		if _, err := plug.Write([]byte(s + "\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write("a.go:1: [DEBUG]debug 1")
+	write("a.go:2: [DEBUG]debug 2")
+	write("a.go:3: [DEBUG]debug 3")
+	write("b.go:1: [INFO]info 1")
+	write("b.go:1: [INFO]info 2")
+
+	// the buckets are refilled.
+	clock.Add(time.Second)
+	write("a.go:4: [DEBUG]debug 4")
+	write("b.go:1: [INFO]info 3")
+
+	// the notice is emitted with the next record after the interval.
+	clock.Add(10 * time.Second)
+	write("b.go:1: [INFO]info 4")
+	write("b.go:1: [INFO]info 5")
+
+	if err := plug.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		`{"level":"DEBUG","location":"a.go:1","message":"debug 1"}`,
+		`{"level":"DEBUG","location":"a.go:2","message":"debug 2"}`,
+		`{"level":"INFO","location":"b.go:1","message":"info 1"}`,
+		`{"level":"DEBUG","location":"a.go:4","message":"debug 4"}`,
+		`{"level":"INFO","location":"b.go:1","message":"info 3"}`,
+		`{"message":"logplug: 2 records suppressed","suppressed":{"level:DEBUG":1,"location:b.go:1":1}}`,
+		`{"level":"INFO","location":"b.go:1","message":"info 4"}`,
+		`{"message":"logplug: 1 records suppressed","suppressed":{"location:b.go:1":1}}`,
+	}
+	got := strings.TrimRight(buf.String(), "\n")
+	if got != strings.Join(want, "\n") {
+		t.Errorf("mismatch output\ngot:\n%s\nwant:\n%s", got, strings.Join(want, "\n"))
+	}
+}
+
+func TestRateLimitHook_timer(t *testing.T) {
+	var buf syncBuffer
+	l := log.New(logplug.NewJSONPlug(&buf, logplug.Hooks(
+		logplug.LevelHook(logplug.LevelConfig{Levels: []logplug.Level{"INFO"}}),
+		logplug.RateLimitHook(logplug.RateLimitConfig{
+			Levels:         map[logplug.Level]logplug.RateLimit{"INFO": {Burst: 1}},
+			NoticeInterval: 10 * time.Millisecond,
+		}),
+	)), "", 0)
+
+	for i := 0; i < 3; i++ {
+		l.Print("[INFO]flood")
+	}
+	for i := 0; i < 100 && strings.Count(buf.String(), "\n") < 2; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	want := `{"level":"INFO","message":"flood"}` + "\n" +
+		`{"message":"logplug: 2 records suppressed","suppressed":{"level:INFO":2}}` + "\n"
+	if buf.String() != want {
+		t.Errorf("mismatch output\ngot:  %swant: %s", buf.String(), want)
+	}
+}
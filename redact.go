@@ -0,0 +1,195 @@
+package logplug
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Patterns of sensitive data.
+// The matches of CreditCardPattern are redacted by RedactHook only when they pass the Luhn checksum.
+var (
+	CreditCardPattern = regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`)
+	JWTPattern        = regexp.MustCompile(`\beyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)
+	EmailPattern      = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+	BearerPattern     = regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9._~+/-]+=*`)
+)
+
+// DefaultRedactPatterns are patterns of credit card, JWT, email and bearer token.
+var DefaultRedactPatterns = []*regexp.Regexp{BearerPattern, JWTPattern, EmailPattern, CreditCardPattern}
+
+// RedactStrategy replaces a sensitive value.
+// If ok is false, the field is dropped or the matched text is removed.
+type RedactStrategy func(s string) (replaced string, ok bool)
+
+// Mask replaces sensitive values with replacement.
+func Mask(replacement string) RedactStrategy {
+	return func(string) (string, bool) {
+		return replacement, true
+	}
+}
+
+// Hash replaces sensitive values with sha256 of salt and the value.
+// The same value is converted to the same hash, so the logs can be correlated.
+func Hash(salt string) RedactStrategy {
+	return func(s string) (string, bool) {
+		sum := sha256.Sum256([]byte(salt + s))
+		return "sha256:" + hex.EncodeToString(sum[:]), true
+	}
+}
+
+// Drop drops sensitive fields and removes the matched text.
+func Drop() RedactStrategy {
+	return func(string) (string, bool) {
+		return "", false
+	}
+}
+
+// RedactConfig is option of RedactHook.
+type RedactConfig struct {
+	// Fields are field names of sensitive values. The names are compared case-insensitively.
+	Fields []string
+	// Patterns are applied to the message and string values.
+	Patterns []*regexp.Regexp
+	// Strategy is used to replace sensitive values. Default is Mask("[REDACTED]").
+	Strategy RedactStrategy
+}
+
+// RedactHook redacts sensitive data in fields and messages.
+//	logplug.RedactHook(logplug.RedactConfig{
+//		Fields:   []string{"password", "authorization"},
+//		Patterns: logplug.DefaultRedactPatterns,
+//		Strategy: logplug.Hash("salt"),
+//	})
+//
+// The maps and the slices in the fields are redacted recursively, and the error values are converted to strings.
+// RedactHook should be set after ErrorValueHook to keep ErrorValue.
+func RedactHook(config RedactConfig) Hook {
+	if config.Strategy == nil {
+		config.Strategy = Mask("[REDACTED]")
+	}
+	r := &redactor{
+		conf:   config,
+		fields: make(map[string]struct{}, len(config.Fields)),
+	}
+	for _, field := range config.Fields {
+		r.fields[strings.ToLower(field)] = struct{}{}
+	}
+
+	return func(enc Encoder) Encoder {
+		return EncoderFunc(func(p *Plug, m *MessageElement) error {
+			for key, v := range m.elements {
+				if v, ok := r.redactField(key, v); ok {
+					m.elements[key] = v
+				} else {
+					delete(m.elements, key)
+				}
+			}
+			return enc.Encode(p, m)
+		})
+	}
+}
+
+type redactor struct {
+	conf   RedactConfig
+	fields map[string]struct{}
+}
+
+// redactField redacts v of key. If ok is false, the field should be dropped.
+func (r *redactor) redactField(key string, v interface{}) (redacted interface{}, ok bool) {
+	if _, sensitive := r.fields[strings.ToLower(key)]; sensitive {
+		s, isString := v.(string)
+		if !isString {
+			s = fmt.Sprint(v)
+		}
+		return r.conf.Strategy(s)
+	}
+	return r.redactValue(v), true
+}
+
+// redactValue applies the patterns to strings in v.
+// The maps and the slices are copied so that the shared values aren't modified.
+func (r *redactor) redactValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case string:
+		return r.replace(v)
+	case error:
+		return r.replace(v.Error())
+	case ErrorValue:
+		v.Message = r.replace(v.Message)
+		v.Stack = r.replace(v.Stack)
+		causes := make([]ErrorCause, len(v.Causes))
+		for i, cause := range v.Causes {
+			causes[i] = ErrorCause{Message: r.replace(cause.Message), Type: cause.Type}
+		}
+		if len(causes) > 0 {
+			v.Causes = causes
+		}
+		return v
+	case map[string]interface{}:
+		redacted := make(map[string]interface{}, len(v))
+		for key, e := range v {
+			if e, ok := r.redactField(key, e); ok {
+				redacted[key] = e
+			}
+		}
+		return redacted
+	case map[string]string:
+		redacted := make(map[string]string, len(v))
+		for key, e := range v {
+			if e, ok := r.redactField(key, e); ok {
+				redacted[key] = e.(string)
+			}
+		}
+		return redacted
+	case []interface{}:
+		redacted := make([]interface{}, len(v))
+		for i, e := range v {
+			redacted[i] = r.redactValue(e)
+		}
+		return redacted
+	case []string:
+		redacted := make([]string, len(v))
+		for i, e := range v {
+			redacted[i] = r.replace(e)
+		}
+		return redacted
+	}
+	return v
+}
+
+func (r *redactor) replace(s string) string {
+	for _, pattern := range r.conf.Patterns {
+		s = pattern.ReplaceAllStringFunc(s, func(match string) string {
+			if pattern == CreditCardPattern && !luhn(match) {
+				return match
+			}
+			replaced, _ := r.conf.Strategy(match)
+			return replaced
+		})
+	}
+	return s
+}
+
+// luhn reports whether the digits in s pass the Luhn checksum of card numbers.
+func luhn(s string) bool {
+	var sum, n int
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if n%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		n++
+	}
+	return n > 0 && sum%10 == 0
+}
@@ -0,0 +1,148 @@
+package logplug_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/komem3/logplug"
+)
+
+func TestRedactHook(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		prefix  string
+		message string
+		config  logplug.RedactConfig
+		want    string
+	}{
+		{
+			name:    "mask field",
+			prefix:  "[Password:secret][user:alice]",
+			message: "login",
+			config:  logplug.RedactConfig{Fields: []string{"password"}},
+			want:    `{"Password":"[REDACTED]","message":"login","user":"alice"}`,
+		},
+		{
+			name:    "drop field",
+			prefix:  "[authorization:Basic abc][admin:true]",
+			message: "request",
+			config:  logplug.RedactConfig{Fields: []string{"authorization", "admin"}, Strategy: logplug.Drop()},
+			want:    `{"message":"request"}`,
+		},
+		{
+			name:    "hash field",
+			prefix:  "[token:abc]",
+			message: "hash",
+			config:  logplug.RedactConfig{Fields: []string{"token"}, Strategy: logplug.Hash("salt")},
+			want:    `{"message":"hash","token":"sha256:` + sha256Hex("saltabc") + `"}`,
+		},
+		{
+			name:    "email and card in message",
+			message: "user alice@example.com paid with 4111 1111 1111 1111",
+			config:  logplug.RedactConfig{Patterns: logplug.DefaultRedactPatterns, Strategy: logplug.Mask("***")},
+			want:    `{"message":"user *** paid with ***"}`,
+		},
+		{
+			name:    "bearer and jwt in value",
+			prefix:  "[header:Bearer abc.def-123][jwt:eyJhbGciOiJIUzI1NiJ9.eyJzdWIiOiIxIn0.sig]",
+			message: "tokens",
+			config:  logplug.RedactConfig{Patterns: logplug.DefaultRedactPatterns},
+			want:    `{"header":"[REDACTED]","jwt":"[REDACTED]","message":"tokens"}`,
+		},
+		{
+			name:    "remove matched text",
+			message: "contact alice@example.com now",
+			config:  logplug.RedactConfig{Patterns: []*regexp.Regexp{logplug.EmailPattern}, Strategy: logplug.Drop()},
+			want:    `{"message":"contact  now"}`,
+		},
+		{
+			name:    "digits failing luhn",
+			message: "at 1609459200000000001 order 4111111111111112",
+			config:  logplug.RedactConfig{Patterns: logplug.DefaultRedactPatterns},
+			want:    `{"message":"at 1609459200000000001 order 4111111111111112"}`,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+			log.New(logplug.NewJSONPlug(&buf, logplug.Hooks(logplug.RedactHook(tt.config))), tt.prefix, 0).
+				Print(tt.message)
+
+			if strings.TrimRight(buf.String(), "\n") != tt.want {
+				t.Errorf("mismatch output\ngot:  %swant: %s", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestRedactHook_nested(t *testing.T) {
+	var buf bytes.Buffer
+	logplug.NewLogger(log.New(logplug.NewJSONPlug(&buf, logplug.Hooks(
+		logplug.ErrorValueHook(),
+		logplug.RedactHook(logplug.RedactConfig{
+			Fields:   []string{"password"},
+			Patterns: []*regexp.Regexp{logplug.EmailPattern},
+		}),
+	)), "", 0)).With(
+		"user", map[string]interface{}{"email": "alice@example.com", "password": "secret", "tags": []interface{}{"bob@example.com", 1}},
+		"labels", map[string]string{"owner": "carol@example.com", "password": "secret"},
+		"emails", []string{"dave@example.com"},
+		"error", errors.New("send to erin@example.com failed"),
+	).Print("nested")
+
+	want := `{"emails":["[REDACTED]"],"error":{"message":"send to [REDACTED] failed","type":"*errors.errorString"},` +
+		`"labels":{"owner":"[REDACTED]","password":"[REDACTED]"},"message":"nested",` +
+		`"user":{"email":"[REDACTED]","password":"[REDACTED]","tags":["[REDACTED]",1]}}`
+	if strings.TrimRight(buf.String(), "\n") != want {
+		t.Errorf("mismatch output\ngot:  %swant: %s", buf.String(), want)
+	}
+
+	buf.Reset()
+	logplug.NewLogger(log.New(logplug.NewJSONPlug(&buf, logplug.Hooks(
+		logplug.RedactHook(logplug.RedactConfig{Patterns: []*regexp.Regexp{logplug.EmailPattern}}),
+	)), "", 0)).With("error", errors.New("send to erin@example.com failed")).Print("error")
+
+	want = `{"error":"send to [REDACTED] failed","message":"error"}`
+	if strings.TrimRight(buf.String(), "\n") != want {
+		t.Errorf("mismatch output\ngot:  %swant: %s", buf.String(), want)
+	}
+}
+
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func BenchmarkRedactHook(b *testing.B) {
+	for _, bb := range []struct {
+		name   string
+		config logplug.RedactConfig
+	}{
+		{name: "fields", config: logplug.RedactConfig{Fields: []string{"password", "authorization"}}},
+		{name: "patterns", config: logplug.RedactConfig{Patterns: logplug.DefaultRedactPatterns}},
+		{name: "hash", config: logplug.RedactConfig{
+			Fields:   []string{"password", "authorization"},
+			Patterns: logplug.DefaultRedactPatterns,
+			Strategy: logplug.Hash("salt"),
+		}},
+	} {
+		b.Run(bb.name, func(b *testing.B) {
+			l := log.New(logplug.NewJSONPlug(io.Discard, logplug.Hooks(logplug.RedactHook(bb.config))),
+				"[user:alice][password:secret]", 0)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				l.Print("user alice@example.com logged in with Bearer abc.def")
+			}
+		})
+	}
+}
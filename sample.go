@@ -0,0 +1,193 @@
+package logplug
+
+import (
+	"sync"
+	"time"
+)
+
+// SamplingConfig is option of SamplingHook.
+type SamplingConfig struct {
+	// Tick is interval of sampling. Default is 1s.
+	Tick time.Duration
+	// First is the number of messages logged per key in each tick. Default is 100.
+	First int
+	// Thereafter logs every Thereafter-th message after First. If 0, the messages after First are dropped.
+	Thereafter int
+	// LevelField is field name of level used as a part of the key. Default is "level".
+	LevelField string
+	// Key returns the key of sampling. Default is level and MessageTemplate of the message.
+	Key func(p *Plug, m *MessageElement) string
+
+	// SummaryInterval is interval of the summary of sampled out messages. Default is 1 minute.
+	// The summary is emitted with the next message after the interval,
+	// or by a timer at the interval after the first sampled out message if no message follows.
+	// If negative, the summary is disabled.
+	SummaryInterval time.Duration
+	// SummaryField is field name of the counters in the summary. Default is "sampled_out".
+	SummaryField string
+
+	// Clock returns current time. Default is time.Now.
+	Clock func() time.Time
+}
+
+const samplingCounters = 4096
+
+// MessageTemplate returns msg replaced the digits with '#'.
+// The messages formatted from the same format are likely to have the same template.
+//	"retry 3 failed" -> "retry # failed"
+func MessageTemplate(msg string) string {
+	var (
+		b     []byte
+		digit bool
+	)
+	for i := 0; i < len(msg); i++ {
+		c := msg[i]
+		if '0' <= c && c <= '9' {
+			if b == nil {
+				b = make([]byte, 0, len(msg))
+				b = append(b, msg[:i]...)
+			}
+			if !digit {
+				b = append(b, '#')
+			}
+			digit = true
+			continue
+		}
+		digit = false
+		if b != nil {
+			b = append(b, c)
+		}
+	}
+	if b == nil {
+		return msg
+	}
+	return string(b)
+}
+
+type samplingCounter struct {
+	resetAt time.Time
+	count   int
+}
+
+type sampler struct {
+	conf SamplingConfig
+	enc  Encoder
+
+	mu       sync.Mutex
+	plug     *Plug
+	counters [samplingCounters]samplingCounter
+	summary  *dropSummary
+}
+
+// SamplingHook samples messages to cap repetitive log volume like the sampler of zap.
+// For each key, the first First messages in each Tick are logged and then every Thereafter-th message.
+// The counters of the sampled out messages are emitted periodically and on Plug.Close as a summary message.
+// The error of the summary emitted by the timer is returned by the next Encode or Close.
+//	{"message":"logplug: 12 messages sampled out","sampled_out":{"WARN:retry # failed":12}}
+//
+// The counters are stored in a fixed size table, so different keys may share a counter.
+// SamplingHook should be set after LevelHook to use the level as the key.
+func SamplingHook(config SamplingConfig) Hook {
+	if config.Tick <= 0 {
+		config.Tick = time.Second
+	}
+	if config.First <= 0 {
+		config.First = 100
+	}
+	if config.LevelField == "" {
+		config.LevelField = "level"
+	}
+	if config.Key == nil {
+		config.Key = func(p *Plug, m *MessageElement) string {
+			return m.GetString(config.LevelField) + ":" + MessageTemplate(m.GetString(p.MessageField()))
+		}
+	}
+	if config.SummaryInterval == 0 {
+		config.SummaryInterval = time.Minute
+	}
+	if config.SummaryField == "" {
+		config.SummaryField = "sampled_out"
+	}
+	if config.Clock == nil {
+		config.Clock = time.Now
+	}
+
+	return func(enc Encoder) Encoder {
+		return &sampler{
+			conf:    config,
+			enc:     enc,
+			summary: newDropSummary(config.SummaryInterval, config.Clock()),
+		}
+	}
+}
+
+// Encode implements Encoder.
+func (s *sampler) Encode(p *Plug, m *MessageElement) error {
+	key := s.conf.Key(p, m)
+	now := s.conf.Clock()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.plug = p
+	if s.summary.due(now) {
+		if err := s.emitSummary(p); err != nil {
+			return err
+		}
+	}
+
+	if !s.sample(key, now) {
+		s.summary.add(key, s.fire)
+		return s.summary.takeErr()
+	}
+	if err := s.enc.Encode(p, m); err != nil {
+		return err
+	}
+	return s.summary.takeErr()
+}
+
+// fire emits the summary from the timer.
+func (s *sampler) fire() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.summary.setErr(s.emitSummary(s.plug))
+}
+
+// Close emits the summary of sampled out messages.
+func (s *sampler) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.plug == nil {
+		return nil
+	}
+	if err := s.emitSummary(s.plug); err != nil {
+		return err
+	}
+	return s.summary.takeErr()
+}
+
+func (s *sampler) sample(key string, now time.Time) bool {
+	// fnv-1a
+	hash := uint32(2166136261)
+	for i := 0; i < len(key); i++ {
+		hash ^= uint32(key[i])
+		hash *= 16777619
+	}
+	counter := &s.counters[hash%samplingCounters]
+
+	if !now.Before(counter.resetAt) {
+		counter.resetAt = now.Add(s.conf.Tick)
+		counter.count = 0
+	}
+	counter.count++
+
+	if counter.count <= s.conf.First {
+		return true
+	}
+	return s.conf.Thereafter > 0 && (counter.count-s.conf.First)%s.conf.Thereafter == 0
+}
+
+func (s *sampler) emitSummary(p *Plug) error {
+	return s.summary.emit(p, s.enc, "messages sampled out", s.conf.SummaryField)
+}
@@ -0,0 +1,125 @@
+package logplug_test
+
+import (
+	"bytes"
+	"log"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/komem3/logplug"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) Add(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func TestMessageTemplate(t *testing.T) {
+	for _, tt := range []struct {
+		msg  string
+		want string
+	}{
+		{msg: "no digit", want: "no digit"},
+		{msg: "retry 3 failed", want: "retry # failed"},
+		{msg: "id=12345, code 500", want: "id=#, code #"},
+		{msg: "v1.2.3", want: "v#.#.#"},
+	} {
+		if got := logplug.MessageTemplate(tt.msg); got != tt.want {
+			t.Errorf("MessageTemplate(%q) = %q, want %q", tt.msg, got, tt.want)
+		}
+	}
+}
+
+func TestSamplingHook(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)}
+
+	var buf bytes.Buffer
+	l := log.New(logplug.NewJSONPlug(&buf, logplug.Hooks(
+		logplug.LevelHook(logplug.LevelConfig{Levels: []logplug.Level{"INFO", "WARN"}}),
+		logplug.SamplingHook(logplug.SamplingConfig{
+			Tick:            time.Second,
+			First:           2,
+			Thereafter:      3,
+			SummaryInterval: 10 * time.Second,
+			Clock:           clock.Now,
+		}),
+	)), "", 0)
+
+	for i := 1; i <= 8; i++ {
+		l.Printf("[WARN]retry %d failed", i)
+	}
+	l.Print("[WARN]other")
+
+	// next tick resets the counter.
+	clock.Add(time.Second)
+	l.Print("[WARN]retry 9 failed")
+
+	// summary is emitted with the next message after the interval.
+	clock.Add(10 * time.Second)
+	l.Print("[INFO]after summary")
+
+	want := []string{
+		`{"level":"WARN","message":"retry 1 failed"}`,
+		`{"level":"WARN","message":"retry 2 failed"}`,
+		`{"level":"WARN","message":"retry 5 failed"}`,
+		`{"level":"WARN","message":"retry 8 failed"}`,
+		`{"level":"WARN","message":"other"}`,
+		`{"level":"WARN","message":"retry 9 failed"}`,
+		`{"message":"logplug: 4 messages sampled out","sampled_out":{"WARN:retry # failed":4}}`,
+		`{"level":"INFO","message":"after summary"}`,
+	}
+	got := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("mismatch output\ngot:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestSamplingHook_timer(t *testing.T) {
+	var buf syncBuffer
+	l := log.New(logplug.NewJSONPlug(&buf, logplug.Hooks(
+		logplug.SamplingHook(logplug.SamplingConfig{
+			First:           1,
+			SummaryInterval: 10 * time.Millisecond,
+		}),
+	)), "", 0)
+
+	for i := 0; i < 3; i++ {
+		l.Print("retry")
+	}
+	for i := 0; i < 100 && strings.Count(buf.String(), "\n") < 2; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	want := `{"message":"retry"}` + "\n" +
+		`{"message":"logplug: 2 messages sampled out","sampled_out":{":retry":2}}` + "\n"
+	if buf.String() != want {
+		t.Errorf("mismatch output\ngot:  %swant: %s", buf.String(), want)
+	}
+}
+
+func BenchmarkSamplingHook(b *testing.B) {
+	var buf bytes.Buffer
+	l := log.New(logplug.NewJSONPlug(&buf, logplug.Hooks(
+		logplug.SamplingHook(logplug.SamplingConfig{First: 10, Thereafter: 100}),
+	)), "", 0)
+	msgs := make([]string, 16)
+	for i := range msgs {
+		msgs[i] = "message " + strconv.Itoa(i)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		l.Print(msgs[i%len(msgs)])
+		buf.Reset()
+	}
+}
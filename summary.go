@@ -0,0 +1,90 @@
+package logplug
+
+import (
+	"strconv"
+	"time"
+)
+
+// dropSummaryKeys is max number of keys counted in a summary.
+// The messages of the other keys are counted only in the total.
+const dropSummaryKeys = 100
+
+// dropSummary counts dropped messages by key and emits them periodically as a summary message.
+// The summary is emitted with the next message after the interval, or by the timer when no message follows.
+// dropSummary isn't safe for concurrent use, so the owner must guard it and the timer callback with a lock.
+type dropSummary struct {
+	// interval is interval of the summary. If negative, the summary is disabled.
+	interval time.Duration
+	next     time.Time
+	total    int
+	counts   map[string]int
+	timer    *time.Timer
+	// err is the error of the summary emitted by the timer.
+	err error
+}
+
+func newDropSummary(interval time.Duration, now time.Time) *dropSummary {
+	return &dropSummary{
+		interval: interval,
+		next:     now.Add(interval),
+		counts:   make(map[string]int),
+	}
+}
+
+// add counts a dropped message of key.
+// The first count after the summary starts the timer that calls fire after the interval.
+func (s *dropSummary) add(key string, fire func()) {
+	if s.interval < 0 {
+		return
+	}
+	if s.timer == nil && s.interval > 0 {
+		s.timer = time.AfterFunc(s.interval, fire)
+	}
+	s.total++
+	if _, ok := s.counts[key]; ok || len(s.counts) < dropSummaryKeys {
+		s.counts[key]++
+	}
+}
+
+// due reports whether the summary should be emitted at now and schedules the next summary.
+func (s *dropSummary) due(now time.Time) bool {
+	if s.interval <= 0 || now.Before(s.next) {
+		return false
+	}
+	s.next = now.Add(s.interval)
+	return true
+}
+
+// emit encodes the summary and resets the counts.
+//	{"message":"logplug: <total> <text>","<field>":{"<key>":<count>}}
+func (s *dropSummary) emit(p *Plug, enc Encoder, text, field string) error {
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	if s.total == 0 {
+		return nil
+	}
+
+	m := newMessageElement()
+	defer m.release()
+	m.Set(p.MessageField(), "logplug: "+strconv.Itoa(s.total)+" "+text)
+	m.Set(field, s.counts)
+
+	s.total = 0
+	s.counts = make(map[string]int)
+	return enc.Encode(p, m)
+}
+
+// setErr keeps err of the summary emitted by the timer until takeErr.
+func (s *dropSummary) setErr(err error) {
+	if err != nil && s.err == nil {
+		s.err = err
+	}
+}
+
+func (s *dropSummary) takeErr() error {
+	err := s.err
+	s.err = nil
+	return err
+}
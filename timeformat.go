@@ -0,0 +1,55 @@
+package logplug
+
+import (
+	"time"
+)
+
+// TimeFormatter converts timestamp to the value written by the encoder.
+type TimeFormatter func(t time.Time) interface{}
+
+// Timestamp is timestamp object that has seconds and nanos like google.protobuf.Timestamp.
+// This is the format of timestamp of Cloud Logging.
+type Timestamp struct {
+	Seconds int64 `json:"seconds"`
+	Nanos   int32 `json:"nanos"`
+}
+
+// LayoutFormat formats timestamp with layout.
+func LayoutFormat(layout string) TimeFormatter {
+	return func(t time.Time) interface{} {
+		return t.Format(layout)
+	}
+}
+
+// Built-in formats of timestamp.
+var (
+	// RFC3339Format formats timestamp to string of time.RFC3339.
+	RFC3339Format = LayoutFormat(time.RFC3339)
+	// RFC3339NanoFormat formats timestamp to string of time.RFC3339Nano.
+	RFC3339NanoFormat = LayoutFormat(time.RFC3339Nano)
+	// UnixFormat formats timestamp to number of unix seconds.
+	UnixFormat TimeFormatter = func(t time.Time) interface{} { return t.Unix() }
+	// UnixMilliFormat formats timestamp to number of unix milliseconds.
+	UnixMilliFormat TimeFormatter = func(t time.Time) interface{} { return t.UnixNano() / int64(time.Millisecond) }
+	// UnixNanoFormat formats timestamp to number of unix nanoseconds.
+	UnixNanoFormat TimeFormatter = func(t time.Time) interface{} { return t.UnixNano() }
+	// TimestampObjectFormat formats timestamp to Timestamp.
+	//	{"seconds":1609459200,"nanos":500}
+	TimestampObjectFormat TimeFormatter = func(t time.Time) interface{} {
+		return Timestamp{Seconds: t.Unix(), Nanos: int32(t.Nanosecond())}
+	}
+)
+
+// TimestampFormatHook formats the timestamp field with f.
+// The hooks after TimestampFormatHook can't get the timestamp by GetTime,
+// so TimestampFormat option is recommended to format just before the encoder.
+func TimestampFormatHook(f TimeFormatter) Hook {
+	return func(enc Encoder) Encoder {
+		return EncoderFunc(func(p *Plug, m *MessageElement) error {
+			if t := m.GetTime(p.TimestampField()); !t.IsZero() {
+				m.Set(p.TimestampField(), f(t))
+			}
+			return enc.Encode(p, m)
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package logplug_test
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/komem3/logplug"
+)
+
+func TestTimestampFormat(t *testing.T) {
+	const msg = "2021/01/02 03:04:05.000006 message"
+
+	for _, tt := range []struct {
+		name   string
+		format logplug.TimeFormatter
+		want   string
+	}{
+		{
+			name: "default",
+			want: `{"message":"message","timestamp":"2021-01-02T03:04:05.000006Z"}`,
+		},
+		{
+			name:   "RFC3339",
+			format: logplug.RFC3339Format,
+			want:   `{"message":"message","timestamp":"2021-01-02T03:04:05Z"}`,
+		},
+		{
+			name:   "RFC3339Nano",
+			format: logplug.RFC3339NanoFormat,
+			want:   `{"message":"message","timestamp":"2021-01-02T03:04:05.000006Z"}`,
+		},
+		{
+			name:   "unix",
+			format: logplug.UnixFormat,
+			want:   `{"message":"message","timestamp":1609556645}`,
+		},
+		{
+			name:   "unix milli",
+			format: logplug.UnixMilliFormat,
+			want:   `{"message":"message","timestamp":1609556645000}`,
+		},
+		{
+			name:   "unix nano",
+			format: logplug.UnixNanoFormat,
+			want:   `{"message":"message","timestamp":1609556645000006000}`,
+		},
+		{
+			name:   "object",
+			format: logplug.TimestampObjectFormat,
+			want:   `{"message":"message","timestamp":{"seconds":1609556645,"nanos":6000}}`,
+		},
+		{
+			name:   "layout",
+			format: logplug.LayoutFormat("2006-01-02"),
+			want:   `{"message":"message","timestamp":"2021-01-02"}`,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				buf  bytes.Buffer
+				hook time.Time
+			)
+			opts := []logplug.Option{
+				logplug.LogFlag(log.LstdFlags | log.Lmicroseconds),
+				logplug.Hooks(func(enc logplug.Encoder) logplug.Encoder {
+					return logplug.EncoderFunc(func(p *logplug.Plug, m *logplug.MessageElement) error {
+						hook = m.GetTime(p.TimestampField())
+						return enc.Encode(p, m)
+					})
+				}),
+			}
+			if tt.format != nil {
+				opts = append(opts, logplug.TimestampFormat(tt.format))
+			}
+			if _, err := logplug.NewJSONPlug(&buf, opts...).Write([]byte(msg)); err != nil {
+				t.Fatal(err)
+			}
+
+			if strings.TrimRight(buf.String(), "\n") != tt.want {
+				t.Errorf("mismatch output\ngot:  %swant: %s", buf.String(), tt.want)
+			}
+			if hook.IsZero() {
+				t.Errorf("hook can't get timestamp")
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package logplug
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// FieldType is type of field converted by TransformHook.
+type FieldType int
+
+// Types of field.
+const (
+	StringType FieldType = iota + 1
+	IntType
+	FloatType
+	BoolType
+)
+
+// TransformConfig is option of TransformHook.
+// The transforms are applied in the order of Coerce, Rename, Nest, Drop and Defaults.
+type TransformConfig struct {
+	// Coerce converts fields to the types. If the conversion fails, the value is kept.
+	Coerce map[string]FieldType
+	// Rename renames fields. e.g. {"location": "sourceLocation"}
+	// The values are read from the fields before the renames, so {"a": "b", "b": "a"} swaps a and b.
+	Rename map[string]string
+	// Nest moves fields to the dot separated path. e.g. {"trace": "ctx.trace"}
+	// The values are read from the fields before the moves like Rename.
+	Nest map[string]string
+	// Drop removes fields.
+	Drop []string
+	// Defaults sets values to fields that don't exist.
+	Defaults map[string]interface{}
+}
+
+// TransformHook renames, moves, drops and converts fields by a declarative mapping.
+//	logplug.TransformHook(logplug.TransformConfig{
+//		Coerce:   map[string]logplug.FieldType{"status": logplug.IntType},
+//		Rename:   map[string]string{"location": "sourceLocation"},
+//		Nest:     map[string]string{"trace": "ctx.trace"},
+//		Drop:     []string{"debug"},
+//		Defaults: map[string]interface{}{"service": "api"},
+//	})
+//	// [status:200][trace:abc][debug:x] message -> {"ctx":{"trace":"abc"},"message":"message","service":"api","status":200}
+func TransformHook(config TransformConfig) Hook {
+	renameKeys := make([]string, 0, len(config.Rename))
+	for from := range config.Rename {
+		renameKeys = append(renameKeys, from)
+	}
+	nest := make(map[string][]string, len(config.Nest))
+	nestKeys := make([]string, 0, len(config.Nest))
+	for key, path := range config.Nest {
+		nest[key] = strings.Split(path, ".")
+		nestKeys = append(nestKeys, key)
+	}
+
+	return func(enc Encoder) Encoder {
+		return EncoderFunc(func(p *Plug, m *MessageElement) error {
+			elements := m.Elements()
+
+			for key, typ := range config.Coerce {
+				if v, ok := elements[key]; ok {
+					elements[key] = coerce(v, typ)
+				}
+			}
+			for from, v := range take(elements, renameKeys) {
+				elements[config.Rename[from]] = v
+			}
+			for key, v := range take(elements, nestKeys) {
+				setPath(elements, nest[key], v)
+			}
+			for _, key := range config.Drop {
+				delete(elements, key)
+			}
+			for key, v := range config.Defaults {
+				if _, ok := elements[key]; !ok {
+					elements[key] = v
+				}
+			}
+			return enc.Encode(p, m)
+		})
+	}
+}
+
+// take removes the fields of keys from elements and returns the removed values.
+// All values are read before the removal, so the moves of the values don't depend on the order of keys.
+func take(elements map[string]interface{}, keys []string) map[string]interface{} {
+	if len(keys) == 0 {
+		return nil
+	}
+	taken := make(map[string]interface{}, len(keys))
+	for _, key := range keys {
+		if v, ok := elements[key]; ok {
+			taken[key] = v
+		}
+	}
+	for key := range taken {
+		delete(elements, key)
+	}
+	return taken
+}
+
+func coerce(v interface{}, typ FieldType) interface{} {
+	s, isString := v.(string)
+	switch typ {
+	case StringType:
+		if !isString {
+			return fmt.Sprint(v)
+		}
+	case IntType:
+		if isString {
+			if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+				return i
+			}
+		}
+	case FloatType:
+		if isString {
+			if f, err := strconv.ParseFloat(s, 64); err == nil {
+				return f
+			}
+		}
+	case BoolType:
+		if isString {
+			if b, err := strconv.ParseBool(s); err == nil {
+				return b
+			}
+		}
+	}
+	return v
+}
+
+// setPath sets v to the nested map of elements.
+// The existing maps in the path are copied so that the shared values aren't modified.
+func setPath(elements map[string]interface{}, path []string, v interface{}) {
+	for _, key := range path[:len(path)-1] {
+		child := make(map[string]interface{})
+		if old, ok := elements[key].(map[string]interface{}); ok {
+			for k, e := range old {
+				child[k] = e
+			}
+		}
+		elements[key] = child
+		elements = child
+	}
+	elements[path[len(path)-1]] = v
+}
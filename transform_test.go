@@ -0,0 +1,94 @@
+package logplug_test
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"testing"
+
+	"github.com/komem3/logplug"
+)
+
+func TestTransformHook(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		msg    string
+		option logplug.TransformConfig
+		want   string
+	}{
+		{
+			name:   "coerce",
+			msg:    "[status:200][ratio:0.5][ok:true][code:abc] message",
+			option: logplug.TransformConfig{Coerce: map[string]logplug.FieldType{"status": logplug.IntType, "ratio": logplug.FloatType, "ok": logplug.BoolType, "code": logplug.IntType}},
+			want:   `{"code":"abc","message":"message","ok":true,"ratio":0.5,"status":200}`,
+		},
+		{
+			name:   "coerce string",
+			msg:    "[ok:true] message",
+			option: logplug.TransformConfig{Coerce: map[string]logplug.FieldType{"ok": logplug.StringType}},
+			want:   `{"message":"message","ok":"true"}`,
+		},
+		{
+			name:   "rename",
+			msg:    "[a:1] message",
+			option: logplug.TransformConfig{Rename: map[string]string{"a": "b", "message": "msg"}},
+			want:   `{"b":"1","msg":"message"}`,
+		},
+		{
+			name:   "swap rename",
+			msg:    "[a:1][b:2] message",
+			option: logplug.TransformConfig{Rename: map[string]string{"a": "b", "b": "a"}},
+			want:   `{"a":"2","b":"1","message":"message"}`,
+		},
+		{
+			name:   "chained rename",
+			msg:    "[a:1][b:2] message",
+			option: logplug.TransformConfig{Rename: map[string]string{"a": "b", "b": "c"}},
+			want:   `{"b":"1","c":"2","message":"message"}`,
+		},
+		{
+			name:   "nest",
+			msg:    "[trace:abc][span:def] message",
+			option: logplug.TransformConfig{Nest: map[string]string{"trace": "ctx.trace", "span": "ctx.span"}},
+			want:   `{"ctx":{"span":"def","trace":"abc"},"message":"message"}`,
+		},
+		{
+			name:   "drop",
+			msg:    "[a:1][b:2] message",
+			option: logplug.TransformConfig{Drop: []string{"a"}},
+			want:   `{"b":"2","message":"message"}`,
+		},
+		{
+			name:   "defaults",
+			msg:    "[env:dev] message",
+			option: logplug.TransformConfig{Defaults: map[string]interface{}{"env": "prod", "service": "api"}},
+			want:   `{"env":"dev","message":"message","service":"api"}`,
+		},
+		{
+			name: "order",
+			msg:  "[status:200][debug:x] message",
+			option: logplug.TransformConfig{
+				Coerce:   map[string]logplug.FieldType{"status": logplug.IntType},
+				Rename:   map[string]string{"status": "code"},
+				Nest:     map[string]string{"code": "http.status"},
+				Drop:     []string{"debug"},
+				Defaults: map[string]interface{}{"debug": false},
+			},
+			want: `{"debug":false,"http":{"status":200},"message":"message"}`,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+			log.New(logplug.NewJSONPlug(&buf, logplug.Hooks(
+				logplug.TransformHook(tt.option),
+			)), "", 0).Print(tt.msg)
+
+			if strings.TrimRight(buf.String(), "\n") != tt.want {
+				t.Errorf("mismatch output\ngot:  %swant: %s", buf.String(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package logplug
+
+import (
+	"path"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync/atomic"
+
+	"github.com/komem3/logplug/internal/caller"
+)
+
+// TrimLocationConfig is option of TrimLocationHook.
+// The trims are applied in the order of ModuleRoot, Prefixes and Components.
+type TrimLocationConfig struct {
+	// ModuleRoot trims the directory of the module root.
+	// The root is detected from the call site of a package of Module, and the paths built with -trimpath are also trimmed.
+	ModuleRoot bool
+	// Module is module path used by ModuleRoot. Default is the main module of debug.ReadBuildInfo.
+	Module string
+	// MainPackage is package path of package main used by ModuleRoot. Default is the path of debug.ReadBuildInfo.
+	MainPackage string
+	// Prefixes are trimmed from the location. Only the first matched prefix is trimmed.
+	Prefixes []string
+	// Components keeps the last Components elements of the file path. If 0, all elements are kept.
+	Components int
+}
+
+// rootDetectAttempts is max number of records used to detect the module root.
+const rootDetectAttempts = 100
+
+type locationTrimmer struct {
+	conf     TrimLocationConfig
+	root     atomic.Value
+	attempts int32
+}
+
+// TrimLocationHook trims the long file path of location.
+//	logplug.TrimLocationHook(logplug.TrimLocationConfig{ModuleRoot: true})
+//	// /home/runner/work/app/app/internal/db/db.go:10 -> internal/db/db.go:10
+//
+//	logplug.TrimLocationHook(logplug.TrimLocationConfig{Components: 2})
+//	// /home/runner/work/app/app/internal/db/db.go:10 -> db/db.go:10
+func TrimLocationHook(config TrimLocationConfig) Hook {
+	if config.ModuleRoot {
+		if info, ok := debug.ReadBuildInfo(); ok {
+			if config.Module == "" {
+				config.Module = info.Main.Path
+			}
+			if config.MainPackage == "" {
+				config.MainPackage = info.Path
+			}
+		}
+	}
+
+	return func(enc Encoder) Encoder {
+		t := &locationTrimmer{conf: config}
+		return EncoderFunc(func(p *Plug, m *MessageElement) error {
+			if location := m.GetString(p.LocationField()); location != "" {
+				m.Set(p.LocationField(), t.trim(location, m))
+			}
+			return enc.Encode(p, m)
+		})
+	}
+}
+
+func (t *locationTrimmer) trim(location string, m *MessageElement) string {
+	if t.conf.ModuleRoot && t.conf.Module != "" {
+		for _, prefix := range []string{t.moduleRoot(m), t.conf.Module} {
+			if prefix != "" && strings.HasPrefix(location, prefix+"/") {
+				location = location[len(prefix)+1:]
+				break
+			}
+		}
+	}
+
+	for _, prefix := range t.conf.Prefixes {
+		if strings.HasPrefix(location, prefix) {
+			location = location[len(prefix):]
+			break
+		}
+	}
+
+	if t.conf.Components > 0 {
+		file, line := location, ""
+		if index := strings.LastIndexByte(location, ':'); index != -1 && isFileLine(location) {
+			file, line = location[:index], location[index:]
+		}
+		n := 0
+		for i := len(file) - 1; i >= 0; i-- {
+			if file[i] == '/' {
+				n++
+				if n == t.conf.Components {
+					file = file[i+1:]
+					break
+				}
+			}
+		}
+		location = file + line
+	}
+	return location
+}
+
+// moduleRoot returns the directory of the module root.
+// The root is detected from the frame of the log call site in a package of the module.
+func (t *locationTrimmer) moduleRoot(m *MessageElement) string {
+	if root, _ := t.root.Load().(string); root != "" {
+		return root
+	}
+	if atomic.AddInt32(&t.attempts, 1) > rootDetectAttempts {
+		return ""
+	}
+
+	frames := m.Frames()
+	if len(frames) == 0 {
+		return ""
+	}
+	root := t.detectRoot(frames[0])
+	if root != "" {
+		t.root.Store(root)
+	}
+	return root
+}
+
+// detectRoot returns the directory of the module root from the frame in a package of the module.
+// The package path of package main is MainPackage.
+func (t *locationTrimmer) detectRoot(frame runtime.Frame) string {
+	module := t.conf.Module
+	pkg := strings.TrimSuffix(caller.Package(frame.Function), "_test")
+	if pkg == "main" {
+		pkg = t.conf.MainPackage
+	}
+	if pkg != module && !strings.HasPrefix(pkg, module+"/") {
+		return ""
+	}
+
+	dir := path.Dir(frame.File)
+	if rel := strings.TrimPrefix(pkg, module); rel != "" {
+		if !strings.HasSuffix(dir, rel) {
+			return ""
+		}
+		dir = dir[:len(dir)-len(rel)]
+	}
+	return dir
+}
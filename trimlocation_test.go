@@ -0,0 +1,154 @@
+package logplug_test
+
+import (
+	"bytes"
+	"log"
+	"regexp"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/komem3/logplug"
+)
+
+func TestTrimLocationHook(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		option   logplug.TrimLocationConfig
+		location string
+		want     string
+	}{
+		{
+			name:     "trimpath",
+			option:   logplug.TrimLocationConfig{ModuleRoot: true, Module: "github.com/komem3/logplug"},
+			location: "github.com/komem3/logplug/gcpopt/trace.go:10",
+			want:     "gcpopt/trace.go:10",
+		},
+		{
+			name:     "other module",
+			option:   logplug.TrimLocationConfig{ModuleRoot: true, Module: "github.com/komem3/logplug"},
+			location: "/go/pkg/mod/example.com/lib/lib.go:10",
+			want:     "/go/pkg/mod/example.com/lib/lib.go:10",
+		},
+		{
+			name:     "prefixes",
+			option:   logplug.TrimLocationConfig{Prefixes: []string{"/home/runner/", "/home/"}},
+			location: "/home/runner/work/app/main.go:10",
+			want:     "work/app/main.go:10",
+		},
+		{
+			name:     "components",
+			option:   logplug.TrimLocationConfig{Components: 2},
+			location: "/home/runner/work/app/internal/db/db.go:10",
+			want:     "db/db.go:10",
+		},
+		{
+			name:     "short components",
+			option:   logplug.TrimLocationConfig{Components: 3},
+			location: "db/db.go:10",
+			want:     "db/db.go:10",
+		},
+		{
+			name:     "prefixes and components",
+			option:   logplug.TrimLocationConfig{Prefixes: []string{"C:/src/"}, Components: 1},
+			location: "C:/src/app/main.go:10",
+			want:     "main.go:10",
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+			plug := logplug.NewJSONPlug(&buf,
+				logplug.LogFlag(log.Llongfile),
+				logplug.Hooks(logplug.TrimLocationHook(tt.option)),
+			)
+			if _, err := plug.Write([]byte(tt.location + ": message")); err != nil {
+				t.Fatal(err)
+			}
+
+			want := `{"location":"` + tt.want + `","message":"message"}`
+			if strings.TrimRight(buf.String(), "\n") != want {
+				t.Errorf("mismatch output\ngot:  %swant: %s", buf.String(), want)
+			}
+		})
+	}
+}
+
+func TestTrimLocationHook_moduleRoot(t *testing.T) {
+	var buf bytes.Buffer
+	log.New(logplug.NewJSONPlug(&buf,
+		logplug.LogFlag(log.Llongfile),
+		logplug.Hooks(logplug.TrimLocationHook(logplug.TrimLocationConfig{
+			ModuleRoot: true,
+			Module:     "github.com/komem3/logplug",
+		})),
+	), "", log.Llongfile).Print("message")
+
+	want := `{"location":"trimlocation_test\.go:[0-9]+","message":"message"}`
+	if !regexp.MustCompile("^" + want + "$").MatchString(strings.TrimRight(buf.String(), "\n")) {
+		t.Errorf("mismatch output\ngot:  %swant: %s", buf.String(), want)
+	}
+}
+
+func TestDetectModuleRoot(t *testing.T) {
+	config := logplug.TrimLocationConfig{Module: "example.com/app", MainPackage: "example.com/app/cmd/tool"}
+
+	for _, tt := range []struct {
+		name   string
+		config logplug.TrimLocationConfig
+		frame  runtime.Frame
+		want   string
+	}{
+		{
+			name:   "package of module",
+			config: config,
+			frame:  runtime.Frame{Function: "example.com/app/internal/db.Open", File: "/src/app/internal/db/db.go"},
+			want:   "/src/app",
+		},
+		{
+			name:   "main package",
+			config: config,
+			frame:  runtime.Frame{Function: "main.main", File: "/src/app/cmd/tool/main.go"},
+			want:   "/src/app",
+		},
+		{
+			name:   "main package at module root",
+			config: logplug.TrimLocationConfig{Module: "example.com/app", MainPackage: "example.com/app"},
+			frame:  runtime.Frame{Function: "main.run", File: "/src/app/main.go"},
+			want:   "/src/app",
+		},
+		{
+			name:   "trimpath",
+			config: config,
+			frame:  runtime.Frame{Function: "main.main", File: "example.com/app/cmd/tool/main.go"},
+			want:   "example.com/app",
+		},
+		{
+			name:   "test package",
+			config: config,
+			frame:  runtime.Frame{Function: "example.com/app/internal/db_test.TestOpen", File: "/src/app/internal/db/db_test.go"},
+			want:   "/src/app",
+		},
+		{
+			name:   "package of other module",
+			config: config,
+			frame:  runtime.Frame{Function: "example.com/lib.Do", File: "/go/pkg/mod/example.com/lib/lib.go"},
+		},
+		{
+			name:   "directory mismatch",
+			config: config,
+			frame:  runtime.Frame{Function: "main.main", File: "/src/app/main.go"},
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := logplug.DetectModuleRoot(tt.config, tt.frame); got != tt.want {
+				t.Errorf("mismatch root\ngot:  %s\nwant: %s", got, tt.want)
+			}
+		})
+	}
+}